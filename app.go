@@ -2,10 +2,21 @@
 
 package main
 
-import "github.com/maxence-charriere/go-app/v7/pkg/app"
+import (
+	"github.com/maxence-charriere/go-app/v7/pkg/app"
+	"github.com/maxence-charriere/go-app/v7/pkg/errors"
+)
 
 
 func main() {
-	app.Route("/", &fullpage{ Section: SMenu }) 
+	if err := migrate(app.LocalStorage); err != nil {
+		err = errors.New("error migrating stored data").Wrap(err)
+		app.Log("%s", err)
+		// the logbook is only partly upgraded, keep the app away from it
+		app.Route("/", &errorpage{ Message: err.Error() })
+		app.Run()
+		return
+	}
+	app.Route("/", &fullpage{ Section: SMenu, Store: newLocalStore(app.LocalStorage) }) 
 	app.Run()
 }
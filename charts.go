@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Textualization/boardgame-logbook/stats"
+)
+
+// Charts are drawn as SVG markup and shown with app.Raw: go-app creates its
+// elements in the HTML namespace, where browsers do not draw SVG shapes.
+// Everything is computed here, so the charts work offline with no script.
+
+const (
+	chartWidth = 600
+	chartHeight = 240
+	// room around the plot for the axis labels and the legend
+	chartLeft = 44
+	chartRight = 12
+	chartTop = 24
+	chartBottom = 28
+	chartTicks = 4
+)
+
+var chartColors = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2",
+	"#59a14f", "#edc948", "#b07aa1", "#9c755f",
+}
+
+// chartPoint is a point of a line chart. For dates, X is a Unix time.
+type chartPoint struct {
+	X float64
+	Y float64
+}
+
+// chartSeries is one line of a line chart.
+type chartSeries struct {
+	Name string
+	Points []chartPoint
+}
+
+// monthCount is how many of something happened in a month. Months are
+// numbered year * 12 + month - 1, so they follow each other.
+type monthCount struct {
+	Month int
+	Count int
+}
+
+func monthOf(Date int64) int {
+	t := time.Unix(Date, 0)
+	return t.Year() * 12 + int(t.Month()) - 1
+}
+
+func monthLabel(Month int) string {
+	return fmt.Sprintf("%04d-%02d", Month / 12, Month % 12 + 1)
+}
+
+// countByMonth counts the dates in every month from First to Last, both
+// included, months without dates counting 0.
+func countByMonth(Dates []int64, First int, Last int) []monthCount {
+	Counts := make([]monthCount, 0, Last - First + 1)
+	for Month := First; Month <= Last; Month++ {
+		Counts = append(Counts, monthCount{Month: Month})
+	}
+	for _, Date := range Dates {
+		if Month := monthOf(Date); Month >= First && Month <= Last {
+			Counts[Month - First].Count++
+		}
+	}
+	return Counts
+}
+
+// sessionsPerMonth counts the sessions from the month of the first one to the
+// month of the last one.
+func sessionsPerMonth(l stats.Logbook) []monthCount {
+	Dates := make([]int64, 0, len(l.Sessions))
+	for _, Session := range l.Sessions {
+		Dates = append(Dates, Session.Date)
+	}
+	if len(Dates) == 0 {
+		return nil
+	}
+	sort.Slice(Dates, func(i, j int) bool { return Dates[i] < Dates[j] })
+	return countByMonth(Dates, monthOf(Dates[0]), monthOf(Dates[len(Dates) - 1]))
+}
+
+// boardMonths is how many times a board was played each month.
+type boardMonths struct {
+	Board int
+	Months []monthCount
+}
+
+// playsPerMonth counts the plays of the Top most played boards each month,
+// over the months with games. The most played board comes first.
+func playsPerMonth(l stats.Logbook, Top int) []boardMonths {
+	if len(l.Games) == 0 {
+		return nil
+	}
+	dates := map[int][]int64{}
+	First, Last := math.MaxInt32, 0
+	for _, Game := range l.Games {
+		Date := l.Sessions[Game.Session].Date
+		dates[Game.Board] = append(dates[Game.Board], Date)
+		if Month := monthOf(Date); Month < First {
+			First = Month
+		}
+		if Month := monthOf(Date); Month > Last {
+			Last = Month
+		}
+	}
+	Boards := make([]int, 0, len(dates))
+	for Board := range dates {
+		Boards = append(Boards, Board)
+	}
+	sort.SliceStable(Boards, func(i, j int) bool {
+		if len(dates[Boards[i]]) != len(dates[Boards[j]]) {
+			return len(dates[Boards[i]]) > len(dates[Boards[j]])
+		}
+		return Boards[i] < Boards[j]
+	})
+	if len(Boards) > Top {
+		Boards = Boards[:Top]
+	}
+	Plays := make([]boardMonths, len(Boards))
+	for idx, Board := range Boards {
+		Plays[idx] = boardMonths{Board: Board, Months: countByMonth(dates[Board], First, Last)}
+	}
+	return Plays
+}
+
+// histogramBin counts the scores from Low included to High excluded.
+type histogramBin struct {
+	Low float32
+	High float32
+	Count int
+}
+
+// scoreHistogram spreads the player scores of a board in at most Bins bins of
+// the same width, rounded to whole points. Only boards won by the highest or
+// the lowest score have scores to spread, as for the board statistics.
+func scoreHistogram(l stats.Logbook, Board board, Bins int) []histogramBin {
+	if Board.Scoring != stats.HighestWins && Board.Scoring != stats.LowestWins {
+		return nil
+	}
+	Scores := make([]float32, 0)
+	for _, Game := range l.Games {
+		if Game.Board != Board.ID || Game.PositionalScores {
+			continue
+		}
+		for _, Player := range Game.Players {
+			if Score, ok := l.Scores[Game.ID][Player]; ok {
+				Scores = append(Scores, Score)
+			}
+		}
+	}
+	if len(Scores) == 0 {
+		return nil
+	}
+	Low, High := Scores[0], Scores[0]
+	for _, Score := range Scores {
+		if Score < Low {
+			Low = Score
+		}
+		if Score > High {
+			High = Score
+		}
+	}
+	// bins are counted in float64: adding a width of 1 to a large float32
+	// score can leave it unchanged
+	Min := math.Floor(float64(Low))
+	Width := math.Ceil((float64(High) - Min + 1) / float64(Bins))
+	Histogram := make([]histogramBin, int((float64(High) - Min) / Width) + 1)
+	for bin := range Histogram {
+		Histogram[bin].Low = float32(Min + float64(bin) * Width)
+		Histogram[bin].High = float32(Min + float64(bin + 1) * Width)
+	}
+	for _, Score := range Scores {
+		bin := int((float64(Score) - Min) / Width)
+		if bin >= len(Histogram) {
+			bin = len(Histogram) - 1
+		}
+		Histogram[bin].Count++
+	}
+	return Histogram
+}
+
+// ratingSeries turns the rating history of players into chart lines, in the
+// order of the players given.
+func ratingSeries(R ratings, Players []int, AllPlayers []player) []chartSeries {
+	Series := make([]chartSeries, 0, len(Players))
+	for _, Player := range Players {
+		Line := chartSeries{Name: findPlayer(AllPlayers, Player).Text}
+		for _, Point := range R.History[Player] {
+			Line.Points = append(Line.Points, chartPoint{X: float64(Point.Date), Y: Point.Rating})
+		}
+		Series = append(Series, Line)
+	}
+	return Series
+}
+
+// monthBars labels the month counts for a bar chart.
+func monthBars(Counts []monthCount) ([]string, []float64) {
+	Labels := make([]string, len(Counts))
+	Values := make([]float64, len(Counts))
+	for idx, Count := range Counts {
+		Labels[idx] = monthLabel(Count.Month)
+		Values[idx] = float64(Count.Count)
+	}
+	return Labels, Values
+}
+
+// histogramBars labels the bins for a bar chart, by the whole scores they
+// hold: "10" or "10-14".
+func histogramBars(Histogram []histogramBin) ([]string, []float64) {
+	Labels := make([]string, len(Histogram))
+	Values := make([]float64, len(Histogram))
+	for idx, Bin := range Histogram {
+		Labels[idx] = formatScore(Bin.Low)
+		if Bin.High - Bin.Low > 1 {
+			Labels[idx] += "-" + formatScore(Bin.High - 1)
+		}
+		Values[idx] = float64(Bin.Count)
+	}
+	return Labels, Values
+}
+
+// boardLines turns the plays per month into chart lines, X being the month.
+func boardLines(Plays []boardMonths, Boards map[int]board) []chartSeries {
+	Series := make([]chartSeries, len(Plays))
+	for idx, Board := range Plays {
+		Series[idx].Name = Boards[Board.Board].Text
+		for _, Count := range Board.Months {
+			Series[idx].Points = append(Series[idx].Points, chartPoint{X: float64(Count.Month), Y: float64(Count.Count)})
+		}
+	}
+	return Series
+}
+
+func monthTick(X float64) string {
+	return monthLabel(int(math.Round(X)))
+}
+
+func dateTick(X float64) string {
+	return time.Unix(int64(X), 0).Format("2006-01-02")
+}
+
+// barChart draws one bar per label. With many bars only some of the labels
+// are written under them, all of them are in the tooltips.
+func barChart(Labels []string, Values []float64) string {
+	var b strings.Builder
+	chartStart(&b)
+	if len(Values) == 0 {
+		return chartEnd(&b)
+	}
+	Max := 0.0
+	for _, Value := range Values {
+		Max = math.Max(Max, Value)
+	}
+	_, Max = niceRange(0, Max)
+	chartYAxis(&b, 0, Max)
+
+	Slot := float64(chartWidth - chartLeft - chartRight) / float64(len(Values))
+	every := int(math.Ceil(float64(len(Values)) * 60 / float64(chartWidth - chartLeft - chartRight)))
+	for idx, Value := range Values {
+		x := chartLeft + Slot * float64(idx)
+		y := chartY(Value, 0, Max)
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %v</title></rect>`,
+			x + Slot * 0.1, y, Slot * 0.8, chartHeight - chartBottom - y, chartColors[0],
+			html.EscapeString(Labels[idx]), Value)
+		if idx % every == 0 {
+			chartXLabel(&b, x + Slot / 2, Labels[idx], "middle")
+		}
+	}
+	return chartEnd(&b)
+}
+
+// lineChart draws each series as a line of its own colour, with a legend on
+// top. xLabel writes the values on the horizontal axis.
+func lineChart(Series []chartSeries, xLabel func(float64) string) string {
+	var b strings.Builder
+	chartStart(&b)
+	MinX, MaxX := math.Inf(1), math.Inf(-1)
+	MinY, MaxY := math.Inf(1), math.Inf(-1)
+	for _, Line := range Series {
+		for _, Point := range Line.Points {
+			MinX, MaxX = math.Min(MinX, Point.X), math.Max(MaxX, Point.X)
+			MinY, MaxY = math.Min(MinY, Point.Y), math.Max(MaxY, Point.Y)
+		}
+	}
+	if math.IsInf(MinX, 1) {
+		return chartEnd(&b)
+	}
+	if MaxX == MinX {
+		MinX, MaxX = MinX - 1, MaxX + 1
+	}
+	MinY, MaxY = niceRange(MinY, MaxY)
+	chartYAxis(&b, MinY, MaxY)
+	for tick := 0; tick <= chartTicks; tick++ {
+		X := MinX + (MaxX - MinX) * float64(tick) / chartTicks
+		// the labels at both ends stay inside the chart
+		anchor := "middle"
+		if tick == 0 {
+			anchor = "start"
+		} else if tick == chartTicks {
+			anchor = "end"
+		}
+		chartXLabel(&b, chartX(X, MinX, MaxX), xLabel(X), anchor)
+	}
+
+	legend := float64(chartLeft)
+	for idx, Line := range Series {
+		Color := chartColors[idx % len(chartColors)]
+		points := make([]string, len(Line.Points))
+		for pidx, Point := range Line.Points {
+			x, y := chartX(Point.X, MinX, MaxX), chartY(Point.Y, MinY, MaxY)
+			points[pidx] = fmt.Sprintf("%.1f,%.1f", x, y)
+			if len(Line.Points) == 1 {
+				// a line of one point would not show
+				fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`, x, y, Color)
+			}
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"><title>%s</title></polyline>`,
+			strings.Join(points, " "), Color, html.EscapeString(Line.Name))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="6" width="10" height="10" fill="%s"/>`, legend, Color)
+		fmt.Fprintf(&b, `<text x="%.1f" y="15" font-size="11">%s</text>`, legend + 14, html.EscapeString(Line.Name))
+		legend += 14 + 7 * float64(len([]rune(Line.Name))) + 12
+	}
+	return chartEnd(&b)
+}
+
+func chartStart(b *strings.Builder) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="100%%" style="max-width: %dpx" font-family="sans-serif">`,
+		chartWidth, chartHeight, chartWidth)
+}
+
+func chartEnd(b *strings.Builder) string {
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// chartYAxis draws the horizontal grid lines with their values.
+func chartYAxis(b *strings.Builder, Min float64, Max float64) {
+	for tick := 0; tick <= chartTicks; tick++ {
+		Value := Min + (Max - Min) * float64(tick) / chartTicks
+		y := chartY(Value, Min, Max)
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ccc"/>`,
+			chartLeft, y, chartWidth - chartRight, y)
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" font-size="10" text-anchor="end">%s</text>`,
+			chartLeft - 4, y + 3, fmt.Sprintf("%.0f", Value))
+	}
+}
+
+func chartXLabel(b *strings.Builder, x float64, Label string, anchor string) {
+	fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="10" text-anchor="%s">%s</text>`,
+		x, chartHeight - chartBottom + 14, anchor, html.EscapeString(Label))
+}
+
+func chartX(X float64, Min float64, Max float64) float64 {
+	return chartLeft + (X - Min) / (Max - Min) * float64(chartWidth - chartLeft - chartRight)
+}
+
+func chartY(Y float64, Min float64, Max float64) float64 {
+	return chartHeight - chartBottom - (Y - Min) / (Max - Min) * float64(chartHeight - chartTop - chartBottom)
+}
+
+// niceRange widens Min and Max to a range whose grid lines fall on round
+// values, at least one apart.
+func niceRange(Min float64, Max float64) (float64, float64) {
+	span := math.Max(Max - Min, 1)
+	mag := math.Max(math.Pow(10, math.Floor(math.Log10(span / chartTicks))), 1)
+	for {
+		for _, m := range []float64{1, 2, 5} {
+			step := m * mag
+			Low := math.Floor(Min / step) * step
+			if Low + step * chartTicks >= Max {
+				return Low, Low + step * chartTicks
+			}
+		}
+		mag *= 10
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Textualization/boardgame-logbook/stats"
+)
+
+// midMonth is a date in the middle of a month, in the month whatever the
+// time zone.
+func midMonth(Year int, Month time.Month) int64 {
+	return time.Date(Year, Month, 15, 12, 0, 0, 0, time.Local).Unix()
+}
+
+// scoresLogbook has one game on board 0 per score.
+func scoresLogbook(Scoring stats.ScoringMode, Scores ...float32) stats.Logbook {
+	l := stats.Logbook{
+		Sessions: map[int]session{0: {ID: 0, Date: 1600000000}},
+		Scores: map[int]map[int]float32{},
+		Boards: map[int]board{0: {ID: 0, Text: "Catan", Scoring: Scoring}},
+	}
+	for ID, Score := range Scores {
+		l.Games = append(l.Games, game{ID: ID, Board: 0, Session: 0, Players: []int{Ann}})
+		l.Scores[ID] = map[int]float32{Ann: Score}
+	}
+	return l
+}
+
+func TestScoreHistogram(t *testing.T) {
+	for _, c := range []struct {
+		Name string
+		Scoring stats.ScoringMode
+		Scores []float32
+		Bins int
+		want []histogramBin
+	}{
+		{"spread", stats.HighestWins, []float32{0, 1, 4, 5, 9}, 5, []histogramBin{
+			{0, 2, 2}, {2, 4, 0}, {4, 6, 2}, {6, 8, 0}, {8, 10, 1},
+		}},
+		// 10 is the High of the second bin, so it opens a third one
+		{"score on the top edge", stats.HighestWins, []float32{0, 5, 10}, 2, []histogramBin{
+			{0, 6, 2}, {6, 12, 1},
+		}},
+		{"top edge of the last bin", stats.HighestWins, []float32{0, 5, 9}, 2, []histogramBin{
+			{0, 5, 1}, {5, 10, 2},
+		}},
+		{"negative", stats.LowestWins, []float32{-7, -3, 0, 2}, 3, []histogramBin{
+			{-7, -3, 1}, {-3, 1, 2}, {1, 5, 1},
+		}},
+		{"fractional", stats.HighestWins, []float32{-0.5, 0.25, 1.75, 2.5}, 2, []histogramBin{
+			{-1, 2, 3}, {2, 5, 1},
+		}},
+		{"one score", stats.HighestWins, []float32{7, 7, 7}, 5, []histogramBin{
+			{7, 8, 3},
+		}},
+		{"one large score", stats.HighestWins, []float32{1e9}, 5, []histogramBin{
+			{1e9, 1e9 + 1, 1},
+		}},
+		{"no bins for cooperative boards", stats.Cooperative, []float32{1, 2}, 5, nil},
+		{"no scores", stats.HighestWins, nil, 5, nil},
+	} {
+		got := scoreHistogram(scoresLogbook(c.Scoring, c.Scores...), board{ID: 0, Scoring: c.Scoring}, c.Bins)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%v: histogram = %v; want %v", c.Name, got, c.want)
+		}
+		if len(got) > c.Bins {
+			t.Errorf("%v: %v bins; want at most %v", c.Name, len(got), c.Bins)
+		}
+	}
+}
+
+func TestNiceRange(t *testing.T) {
+	for _, c := range []struct {
+		Min, Max float64
+		Low, High float64
+	}{
+		{0, 10, 0, 20},
+		{1420, 1580, 1400, 1600},
+		{-3.5, 2, -4, 4},
+		{0.2, 0.9, 0, 4},
+		// a single value still gets a range
+		{5, 5, 5, 9},
+		{0, 0, 0, 4},
+		{-2, -2, -2, 2},
+	} {
+		Low, High := niceRange(c.Min, c.Max)
+		if Low != c.Low || High != c.High {
+			t.Errorf("niceRange(%v, %v) = %v, %v; want %v, %v", c.Min, c.Max, Low, High, c.Low, c.High)
+		}
+		if Low > c.Min || High < c.Max || High <= Low {
+			t.Errorf("niceRange(%v, %v) = %v, %v; want a range around them", c.Min, c.Max, Low, High)
+		}
+	}
+}
+
+func TestCountByMonth(t *testing.T) {
+	First, Last := monthOf(midMonth(2020, time.November)), monthOf(midMonth(2021, time.March))
+	Dates := []int64{
+		midMonth(2020, time.November), midMonth(2020, time.November),
+		midMonth(2021, time.February),
+		// outside the months counted
+		midMonth(2020, time.October), midMonth(2021, time.April),
+	}
+	got := countByMonth(Dates, First, Last)
+	want := []monthCount{{First, 2}, {First + 1, 0}, {First + 2, 0}, {First + 3, 1}, {First + 4, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("counts = %v; want %v", got, want)
+	}
+	if Label := monthLabel(First + 2); Label != "2021-01" {
+		t.Errorf("label = %v; want 2021-01, the month after December", Label)
+	}
+}
+
+func TestPlaysPerMonth(t *testing.T) {
+	l := stats.Logbook{
+		Sessions: map[int]session{
+			0: {ID: 0, Date: midMonth(2020, time.January)},
+			1: {ID: 1, Date: midMonth(2020, time.April)},
+		},
+		Games: []game{
+			{ID: 0, Board: 2, Session: 0},
+			{ID: 1, Board: 1, Session: 0},
+			{ID: 2, Board: 1, Session: 1},
+			{ID: 3, Board: 0, Session: 1},
+		},
+	}
+	Jan := monthOf(midMonth(2020, time.January))
+	got := playsPerMonth(l, 2)
+	// board 1 first, with more plays, then board 0 before board 2 by ID; the
+	// months without games in between count 0
+	want := []boardMonths{
+		{Board: 1, Months: []monthCount{{Jan, 1}, {Jan + 1, 0}, {Jan + 2, 0}, {Jan + 3, 1}}},
+		{Board: 0, Months: []monthCount{{Jan, 0}, {Jan + 1, 0}, {Jan + 2, 0}, {Jan + 3, 1}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plays = %v; want %v", got, want)
+	}
+	if got := playsPerMonth(stats.Logbook{}, 2); got != nil {
+		t.Errorf("plays of an empty logbook = %v; want none", got)
+	}
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"math"
+	"math/rand"
+	"sort"
 	"strings"
 	"time"
 	"strconv"
 	"encoding/json"
 	"fmt"
-//	"net/url"
 	
 	"github.com/maxence-charriere/go-app/v7/pkg/app"
 	"github.com/maxence-charriere/go-app/v7/pkg/errors"
+
+	"github.com/Textualization/boardgame-logbook/stats"
 )
 
 type section int
@@ -23,40 +27,61 @@ const (
 	SPlayers
 	SGames
 	SDownload
+	SImport
+	SEditGame
+	SLocations
+	SPlayer
+	SBoard
+	SRatings
+	SHeadToHead
+	SCharts
 )
 
 type fullpage struct {
 	app.Compo
 
 	Section section
+	Store Store
 	
 	// for downpages
 	Session int
 	Game int
+	Player int
+	Board int
+	// the other player on the head to head page, -1 for none
+	Rival int
 	Previous section
 }
 
 func (f *fullpage) Render() app.UI {
 	if f.Section == SDownload {
 		return app.Div().Body(
-			&downloadpage { Full: f },
+			&downloadpage { Full: f, Store: f.Store },
 		)
 	}
 	return app.Div().Body(
 		app.H1().Text("Personal Boardgame Logbook"),
 		app.If(f.Section == SMenu, &mainmenu{ Full: f },).
-			ElseIf(f.Section == SSession, &sessionpage { Full: f, SessionID: f.Session },).
-			ElseIf(f.Section == SNewGame, &newgamepage { Full: f, SessionID: f.Session },).
-			ElseIf(f.Section == SSessions, &sessionspage { Full: f },).
-			ElseIf(f.Section == SPlayers, &playerspage { Full: f },).
-			ElseIf(f.Section == SGames, &boardspage { Full: f },).
-			ElseIf(f.Section == SGame, &gamepage { Full: f, SessionID: f.Session, GameID: f.Game },),
+			ElseIf(f.Section == SSession, &sessionpage { Full: f, Store: f.Store, SessionID: f.Session },).
+			ElseIf(f.Section == SNewGame, &newgamepage { Full: f, Store: f.Store, SessionID: f.Session },).
+			ElseIf(f.Section == SEditGame, &newgamepage { Full: f, Store: f.Store, SessionID: f.Session, Editing: true, GameID: f.Game },).
+			ElseIf(f.Section == SSessions, &sessionspage { Full: f, Store: f.Store },).
+			ElseIf(f.Section == SPlayers, &playerspage { Full: f, Store: f.Store },).
+			ElseIf(f.Section == SGames, &boardspage { Full: f, Store: f.Store },).
+			ElseIf(f.Section == SImport, &importpage { Full: f, Store: f.Store },).
+			ElseIf(f.Section == SLocations, &locationspage { Full: f, Store: f.Store },).
+			ElseIf(f.Section == SPlayer, &playerpage { Full: f, Store: f.Store, PlayerID: f.Player },).
+			ElseIf(f.Section == SBoard, &boardpage { Full: f, Store: f.Store, BoardID: f.Board },).
+			ElseIf(f.Section == SRatings, &ratingspage { Full: f, Store: f.Store },).
+			ElseIf(f.Section == SCharts, &chartspage { Full: f, Store: f.Store },).
+			ElseIf(f.Section == SHeadToHead, &headtoheadpage { Full: f, Store: f.Store, A: f.Player, B: f.Rival },).
+			ElseIf(f.Section == SGame, &gamepage { Full: f, Store: f.Store, SessionID: f.Session, GameID: f.Game },),
 
 	)
 }
 
 func (f *fullpage) newSession() error {
-	Session, err := newSession()
+	Session, err := newSession(f.Store)
 	if err != nil {
 		return errors.New("error creating new session").Wrap(err)
 	}
@@ -73,6 +98,22 @@ func (f *fullpage) download() {
 	f.Update()
 }
 
+// errorpage replaces the whole app when the stored logbook cannot be used.
+type errorpage struct {
+	app.Compo
+
+	Message string
+}
+
+func (e *errorpage) Render() app.UI {
+	return app.Div().Body(
+		app.H1().Text("Personal Boardgame Logbook"),
+		app.P().Text("The stored logbook could not be upgraded to this version of the app. " +
+			"Nothing was changed past the last completed step; reload to try again."),
+		app.Pre().Text(e.Message),
+	)
+}
+
 type mainmenu struct {
 	app.Compo
 
@@ -86,7 +127,12 @@ func (m *mainmenu) Render() app.UI {
 		app.Button().Text("Sessions").OnClick(m.onSessions),
 		app.Button().Text("Players").OnClick(m.onPlayers),
 		app.Button().Text("Games").OnClick(m.onGames),
+		app.Button().Text("Locations").OnClick(m.onLocations),
+		app.Button().Text("Ratings").OnClick(m.onRatings),
+		app.Button().Text("Head to head").OnClick(m.onHeadToHead),
+		app.Button().Text("Charts").OnClick(m.onCharts),
 		app.Button().Text("Download").OnClick(m.onDownload),
+		app.Button().Text("Import").OnClick(m.onImport),
 	))
 }
 
@@ -110,37 +156,108 @@ func (m *mainmenu) onGames(ctx app.Context, e app.Event) {
 	m.Full.Update()
 }
 
+func (m *mainmenu) onLocations(ctx app.Context, e app.Event) {
+	m.Full.Section = SLocations
+	m.Full.Update()
+}
+
+func (m *mainmenu) onRatings(ctx app.Context, e app.Event) {
+	m.Full.Section = SRatings
+	m.Full.Update()
+}
+
+func (m *mainmenu) onHeadToHead(ctx app.Context, e app.Event) {
+	m.Full.Player = -1
+	m.Full.Rival = -1
+	m.Full.Section = SHeadToHead
+	m.Full.Update()
+}
+
+func (m *mainmenu) onCharts(ctx app.Context, e app.Event) {
+	m.Full.Section = SCharts
+	m.Full.Update()
+}
+
 func (m *mainmenu) onDownload(ctx app.Context, e app.Event) {
 	m.Full.download()
 }
 
+func (m *mainmenu) onImport(ctx app.Context, e app.Event) {
+	m.Full.Section = SImport
+	m.Full.Update()
+}
+
 type sessionpage struct {
 	app.Compo
 
 	Full *fullpage
+	Store Store
 	SessionID int
 	Session session
 	Games []game
 	Boards map[int]board
+	// game ID -> who won it
+	Results map[int]string
+	// other sessions the games can be moved to when deleting
+	Sessions []session
+	ConfirmDelete bool
+	MoveTo int
+	AllLocations []location
+	AllPlayers []player
+	LocationInput string
+	AllBoards []board
 }
 
 func (s *sessionpage) OnMount(ctx app.Context) {
 	var err error
-	if s.Session, err = retrieveSession(s.SessionID); err != nil {
+	if s.Session, err = retrieveSession(s.Store, s.SessionID); err != nil {
 		app.Log("%s", errors.New("error fetching session").Wrap(err))
 		return
 	}
-	if s.Games, err = retrieveGamesInSession(s.SessionID); err != nil {
+	if s.Games, err = retrieveGamesInSession(s.Store, s.SessionID); err != nil {
 		app.Log("%s", errors.New("error fetching games for session").Wrap(err))
 		return
 	}
 	s.Boards = map[int]board{}
 	for _, game := range s.Games {
-		if s.Boards[game.Board], err = retrieveBoard(game.Board); err != nil {
+		if s.Boards[game.Board], err = retrieveBoard(s.Store, game.Board); err != nil {
 			app.Log("%s", errors.Newf("error fetching board game %v for session %v", game.Board, s.SessionID).Wrap(err))
 			return
 		}
 	}
+	if s.AllPlayers, err = retrieveAllPlayers(s.Store); err != nil {
+		app.Log("%s", errors.New("error fetching players").Wrap(err))
+		return
+	}
+	if s.AllBoards, err = retrieveAllBoards(s.Store); err != nil {
+		app.Log("%s", errors.New("error fetching boards").Wrap(err))
+		return
+	}
+	if s.AllLocations, err = retrieveAllLocations(s.Store); err != nil {
+		app.Log("%s", errors.New("error fetching locations").Wrap(err))
+		return
+	}
+	s.Results = map[int]string{}
+	for _, Game := range s.Games {
+		Scores, err := retrieveScoresInGameMap(s.Store, Game.ID)
+		if err != nil {
+			app.Log("%s", errors.Newf("error fetching scores of game %v", Game.ID).Wrap(err))
+			return
+		}
+		s.Results[Game.ID] = resultText(s.Boards[Game.Board].Scoring, Game, Scores, s.AllPlayers)
+	}
+	AllSessions, err := retrieveAllSessions(s.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error fetching sessions").Wrap(err))
+		return
+	}
+	s.Sessions = make([]session, 0, len(AllSessions))
+	for _, Session := range AllSessions {
+		if Session.ID != s.SessionID {
+			s.Sessions = append(s.Sessions, Session)
+		}
+	}
+	s.MoveTo = -1
 	s.Update()
 }
 
@@ -148,21 +265,275 @@ func (s *sessionpage) Render() app.UI {
 	theTime := time.Unix(s.Session.Date, 0)
 	return app.Div().Body(
 		app.H2().Text("Session for "  +  theTime.Format("2006-01-02")),
+		app.Div().Body(
+			app.Text("Date: "),
+			app.Input().Type("date").Value(theTime.Format("2006-01-02")).OnChange(s.onDate),
+			app.Text(" started at "),
+			app.Input().Type("time").Value(theTime.Format("15:04")).OnChange(s.onTime),
+		),
+		app.Div().Body(
+			app.Text("Played at "),
+			app.Select().OnChange(s.onLocation).Body(
+				app.Option().Value(-1).Text("no location").Selected(!s.Session.HasLocation),
+				app.Range(s.AllLocations).Slice(func(i int) app.UI {
+					Location := s.AllLocations[i]
+					Selected := s.Session.HasLocation && s.Session.Location == Location.ID
+					if Location.Hidden && !Selected {
+						return app.Text("")
+					}
+					return app.Option().Value(Location.ID).Text(Location.Text).Selected(Selected)
+				}),
+			),
+			app.Text(" or a new place: "),
+			app.Input().Value(s.LocationInput).OnInput(s.onLocationInput),
+			app.Button().Text("New").Disabled(len(strings.TrimSpace(s.LocationInput)) == 0).OnClick(s.onNewLocation),
+		),
+		app.Div().Body(
+			app.Text("Attending: "),
+			app.Range(s.Session.Attendees).Slice(func(i int) app.UI {
+				ID := s.Session.Attendees[i]
+				return app.Span().Body(
+					app.Text(findPlayer(s.AllPlayers, ID).Text),
+					app.Button().Text("-").DataSet("player", ID).OnClick(s.onDelAttendee),
+				)
+			}),
+			app.Select().OnChange(s.onAddAttendee).Body(
+				app.Option().Value(-1).Text("add a player").Selected(true),
+				app.Range(s.AllPlayers).Slice(func(i int) app.UI {
+					Player := s.AllPlayers[i]
+					if Player.Hidden || stats.HasAttendee(s.Session, Player.ID) {
+						return app.Text("")
+					}
+					return app.Option().Value(Player.ID).Text(Player.Text)
+				}),
+			),
+		),
+		app.Div().Body(
+			app.Text("Notes:"),
+			app.Textarea().Rows(3).Cols(60).Text(s.Session.Notes).OnChange(s.onNotes),
+		),
 		app.Button().Text("New Game").OnClick(s.onNewGame),
 		app.Button().Text("Close Session").OnClick(s.onCloseSession),
 		app.Ol().Body(
 			app.Range(s.Games).Slice(func(i int) app.UI {
 				return app.Li().Body(
 					app.Button().
-						Text(s.Boards[s.Games[i].Board].Text).
-						DataSet("game", i).
+						Text(comboName(stats.ComboOf(s.Games[i]), s.AllBoards)).
+						DataSet("game", s.Games[i].ID).
 						OnClick(s.onGame),
+					app.Text(" " + s.Results[s.Games[i].ID]),
 				)},
 			),
 		),
+		app.If(!s.ConfirmDelete,
+			app.Button().Text("Delete Session").OnClick(s.onConfirmDelete),
+		).ElseIf(len(s.Games) == 0,
+			app.Text("Delete this empty session?"),
+			app.Button().Text("yes, delete").OnClick(s.onDelete),
+			app.Button().Text("no").OnClick(s.onCancelDelete),
+		).Else(
+			app.P().Text(fmt.Sprintf("This session has %v games.", len(s.Games))),
+			app.Button().Text("Delete the session and its games").OnClick(s.onDelete),
+			app.If(len(s.Sessions) > 0,
+				app.Div().Body(
+					app.Text("Or move its games to "),
+					app.Select().OnChange(s.onMoveTo).Body(
+						app.Option().Value(-1).Text("choose a session").Selected(s.MoveTo < 0),
+						app.Range(s.Sessions).Slice(func(i int) app.UI {
+							Session := s.Sessions[len(s.Sessions) - i - 1]
+							return app.Option().Value(Session.ID).
+								Text("Session for " + time.Unix(Session.Date, 0).Format("2006-01-02 15:04")).
+								Selected(s.MoveTo == Session.ID)
+						}),
+					),
+					app.Button().Text("Move games and delete").Disabled(s.MoveTo < 0).OnClick(s.onMoveAndDelete),
+				),
+			),
+			app.Button().Text("no").OnClick(s.onCancelDelete),
+		),
 	)
 }
 
+// resultText summarizes who won a game, for the session list. Scores keyed
+// by position do not tell who won.
+func resultText(Mode stats.ScoringMode, Game game, Scores map[int]float32, Players []player) string {
+	if Mode == stats.Cooperative {
+		if Game.Won {
+			return "(won together)"
+		}
+		return "(lost together)"
+	}
+	if Game.PositionalScores {
+		return "(winner unknown)"
+	}
+	Winners := stats.Winners(Mode, Game, Scores)
+	if len(Winners) == 0 {
+		return ""
+	}
+	names := make([]string, len(Winners))
+	for idx, Winner := range Winners {
+		names[idx] = findPlayer(Players, Winner).Text
+	}
+	return "(" + strings.Join(names, ", ") + " won)"
+}
+
+// sessionTime replaces the date or clock of a session time with the value of
+// a date ("2006-01-02") or time ("15:04") input.
+func sessionTime(Date int64, layout string, value string) (int64, error) {
+	theTime := time.Unix(Date, 0)
+	date := theTime.Format("2006-01-02")
+	clock := theTime.Format("15:04")
+	if layout == "2006-01-02" {
+		date = value
+	} else {
+		clock = value
+	}
+	newTime, err := time.ParseInLocation("2006-01-02 15:04", date + " " + clock, time.Local)
+	if err != nil {
+		return Date, errors.Newf("error parsing session time %v %v", date, clock).Wrap(err)
+	}
+	return newTime.Unix(), nil
+}
+
+func (s *sessionpage) setTime(ctx app.Context, layout string) {
+	Date, err := sessionTime(s.Session.Date, layout, ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", err)
+		return
+	}
+	s.Session.Date = Date
+	if err := storeSession(s.Store, s.Session); err != nil {
+		app.Log("%s", errors.New("error storing session date").Wrap(err))
+		return
+	}
+	s.Update()
+}
+
+func (s *sessionpage) onDate(ctx app.Context, e app.Event) {
+	s.setTime(ctx, "2006-01-02")
+}
+
+func (s *sessionpage) onTime(ctx app.Context, e app.Event) {
+	s.setTime(ctx, "15:04")
+}
+
+func (s *sessionpage) saveSession(what string) {
+	if err := storeSession(s.Store, s.Session); err != nil {
+		app.Log("%s", errors.Newf("error storing session %v", what).Wrap(err))
+		return
+	}
+	s.Update()
+}
+
+func (s *sessionpage) onLocation(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown location for onLocation")
+		return
+	}
+	s.Session.HasLocation = i >= 0
+	s.Session.Location = i
+	if i < 0 {
+		s.Session.Location = 0
+	}
+	s.saveSession("location")
+}
+
+func (s *sessionpage) onLocationInput(ctx app.Context, e app.Event) {
+	s.LocationInput = ctx.JSSrc.Get("value").String()
+	s.Update()
+}
+
+func (s *sessionpage) onNewLocation(ctx app.Context, e app.Event) {
+	Location, err := newLocation(s.Store, s.LocationInput)
+	if err != nil {
+		app.Log("%s", errors.New("error creating new location").Wrap(err))
+		return
+	}
+	s.AllLocations = append(s.AllLocations, Location)
+	s.LocationInput = ""
+	s.Session.HasLocation = true
+	s.Session.Location = Location.ID
+	s.saveSession("location")
+}
+
+func (s *sessionpage) onAddAttendee(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onAddAttendee")
+		return
+	}
+	// back to the prompt, for the next player
+	ctx.JSSrc.Set("value", "-1")
+	if id < 0 || stats.HasAttendee(s.Session, id) {
+		return
+	}
+	s.Session.Attendees = append(s.Session.Attendees, id)
+	s.saveSession("attendees")
+}
+
+func (s *sessionpage) onDelAttendee(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onDelAttendee")
+		return
+	}
+	Attendees := make([]int, 0, len(s.Session.Attendees))
+	for _, Player := range s.Session.Attendees {
+		if Player != id {
+			Attendees = append(Attendees, Player)
+		}
+	}
+	s.Session.Attendees = Attendees
+	s.saveSession("attendees")
+}
+
+func (s *sessionpage) onNotes(ctx app.Context, e app.Event) {
+	s.Session.Notes = ctx.JSSrc.Get("value").String()
+	s.saveSession("notes")
+}
+
+func (s *sessionpage) onConfirmDelete(ctx app.Context, e app.Event) {
+	s.ConfirmDelete = true
+	s.Update()
+}
+
+func (s *sessionpage) onCancelDelete(ctx app.Context, e app.Event) {
+	s.ConfirmDelete = false
+	s.Update()
+}
+
+func (s *sessionpage) onMoveTo(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown session for onMoveTo")
+		return
+	}
+	s.MoveTo = i
+	s.Update()
+}
+
+func (s *sessionpage) onDelete(ctx app.Context, e app.Event) {
+	if err := deleteSession(s.Store, s.SessionID); err != nil {
+		app.Log("%s", errors.New("error deleting session").Wrap(err))
+		return
+	}
+	s.closeDeleted()
+}
+
+func (s *sessionpage) onMoveAndDelete(ctx app.Context, e app.Event) {
+	if err := mergeSession(s.Store, s.SessionID, s.MoveTo); err != nil {
+		app.Log("%s", errors.New("error moving session games").Wrap(err))
+		return
+	}
+	s.closeDeleted()
+}
+
+func (s *sessionpage) closeDeleted() {
+	s.Full.Section = SSessions
+	s.Full.Update()
+}
+
 func (s *sessionpage) onCloseSession(ctx app.Context, e app.Event) {
 	s.Full.Section = s.Full.Previous
 	if s.Full.Previous == SSession {
@@ -191,6 +562,7 @@ type newgamepage struct {
 	app.Compo
 
 	Full *fullpage
+	Store Store
 	SessionID int
 	AllBoards []board
 	AllPlayers []player
@@ -200,38 +572,182 @@ type newgamepage struct {
 	PlayerInput string
 	Players []int
 	Scores map[int]float32
+	// score sheet of each player, when the board has categories
+	Breakdown map[int]map[string]float32
+	TeamPlay bool
+	// existing names close to the typed ones
+	BoardSuggestions []board
+	PlayerSuggestions []player
+
+	// editing a recorded game
+	Editing bool
+	GameID int
+	Game game
+	// the scores of a game with PositionalScores, by position, and the
+	// player picked for each position
+	Positional map[int]float32
+	Assigned map[int]int
+
+	// score inputs whose text is not a finite number, by scoreKey; the
+	// game cannot be recorded until they are fixed
+	Invalid map[string]bool
+
+	// closed to stop the timer
+	StopTimer chan struct{}
+	// who the picker chose to go first
+	FirstPick string
 }
 
 func (n *newgamepage) OnMount(ctx app.Context) {
 	var err error
 	n.HasBoard = false
-	if n.AllBoards, err = retrieveAllBoards(); err != nil {
+	if n.AllBoards, err = retrieveAllBoards(n.Store); err != nil {
 		app.Log("%s", errors.New("error fetching all boards").Wrap(err))
 		return
 	}
-	if n.AllPlayers, err = retrieveAllPlayers(); err != nil {
+	if n.AllPlayers, err = retrieveAllPlayers(n.Store); err != nil {
 		app.Log("%s", errors.New("error fetching all players").Wrap(err))
 		return
 	}
 	n.Scores = make(map[int]float32)
+	n.Breakdown = make(map[int]map[string]float32)
+	n.Invalid = make(map[string]bool)
+	if n.Editing {
+		if n.Game, err = retrieveGame(n.Store, n.GameID); err != nil {
+			app.Log("%s", errors.New("error fetching game").Wrap(err))
+			return
+		}
+		if n.Scores, err = retrieveScoresInGameMap(n.Store, n.GameID); err != nil {
+			app.Log("%s", errors.New("error fetching game scores").Wrap(err))
+			return
+		}
+		for Player, Sheet := range copyGame(n.Game).Breakdown {
+			n.Breakdown[Player] = Sheet
+		}
+		n.Board = n.Game.Board
+		n.HasBoard = true
+		n.TeamPlay = len(n.Game.Teams) > 0
+		n.Players = append([]int{}, n.Game.Players...)
+		if n.Game.PositionalScores {
+			// the keys are positions: the players get picked for them first
+			n.Positional = n.Scores
+			n.Assigned = map[int]int{}
+			n.Scores = make(map[int]float32)
+		}
+	} else {
+		Session, err := retrieveSession(n.Store, n.SessionID)
+		if err != nil {
+			app.Log("%s", errors.New("error fetching session").Wrap(err))
+			return
+		}
+		// prefilled from the attendees: the timer waits for the players to be
+		// confirmed, setting up is not playing
+		n.Players = append([]int{}, Session.Attendees...)
+	}
+	n.StopTimer = make(chan struct{})
+	go n.tick(n.StopTimer)
 	n.Update()
 }
 
+func (n *newgamepage) OnDismount() {
+	if n.StopTimer != nil {
+		close(n.StopTimer)
+		n.StopTimer = nil
+	}
+}
+
+// tick refreshes the timer every second while the game is running.
+func (n *newgamepage) tick(stop chan struct{}) {
+	Ticker := time.NewTicker(time.Second)
+	defer Ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-Ticker.C:
+			app.Dispatch(func() { // Ensures update is on UI goroutine.
+				if n.running() {
+					n.Update()
+				}
+			})
+		}
+	}
+}
+
+// running tells whether the timer started and was not stopped yet.
+func (n *newgamepage) running() bool {
+	return n.Game.Start > 0 && n.Game.End == 0
+}
+
+// startTimer starts timing a new game once its players are chosen: when one
+// is added, when the first player is picked or with the start button.
+func (n *newgamepage) startTimer() {
+	if !n.Editing && n.Game.Start == 0 {
+		n.Game.Start = time.Now().Unix()
+	}
+}
+
 func  (n *newgamepage) Render() app.UI {
+	Board := findBoard(n.AllBoards, n.Board)
 	gameOf := ""
 	if n.HasBoard {
-		gameOf = n.AllBoards[n.Board].Text
+		gameOf = Board.Text
+	}
+	scoreLabel := ". Score:"
+	if Board.Scoring == stats.RankOnly {
+		scoreLabel = ". Place:"
+	}
+	title := "New Game"
+	if n.Editing {
+		title = "Edit Game"
+	}
+	Categories := n.categories()
+	Addons := addonsOf(n.AllBoards, n.Board)
+	// players scored on the sheet, the ones in a team share the team score
+	Solo := make([]int, 0, len(n.Players))
+	for _, Player := range n.Players {
+		if stats.TeamOf(n.Game, Player) < 0 {
+			Solo = append(Solo, Player)
+		}
 	}
 	return app.Div().Body(
 		app.If(n.HasBoard,
-			app.H2().Text("New Game of " + gameOf),
+			app.H2().Text(title + " of " + gameOf),
+			app.Button().Text("Change game").OnClick(n.onChangeBoard),
+			app.Div().Body(
+				app.Text("Scoring: "),
+				scoringSelect(Board.Scoring, n.onScoring),
+			),
+			app.If(len(Addons) > 0,
+				app.Div().Body(
+					app.Text("Played with: "),
+					app.Range(Addons).Slice(func(i int) app.UI {
+						return app.Span().Body(
+							app.Input().Type("checkbox").
+								DataSet("board", Addons[i].ID).
+								Checked(hasExpansion(n.Game, Addons[i].ID)).
+								OnChange(n.onExpansion),
+							app.Text(Addons[i].Text + " "),
+						)
+					}),
+				),
+			),
 		).Else(
-			app.H2().Text("New Game"),
+			app.H2().Text(title),
 			app.H3().Text("Choose a boardgame (or type a name to create it)"),
 			app.Div().Body(
 				app.Input().
 					OnInput(n.onBoardChange),//.OnKeyPress(n.onBoardChangeKey),
-				app.Button().Text("New").OnClick(n.onNewBoard).Disabled(len(n.BoardInput) == 0),
+				app.If(len(n.BoardSuggestions) > 0,
+					app.Text("Did you mean "),
+					app.Range(n.BoardSuggestions).Slice(func(i int) app.UI {
+						return app.Button().Text(n.BoardSuggestions[i].Text + "?").
+							DataSet("board", n.BoardSuggestions[i].ID).OnClick(n.onSetBoard)
+					}),
+					app.Button().Text("No, create " + n.BoardInput).OnClick(n.onNewBoard),
+				).Else(
+					app.Button().Text("New").OnClick(n.onNewBoard).Disabled(len(n.BoardInput) == 0),
+				),
 				app.Ul().Body(
 					app.Range(n.AllBoards).Slice(func(i int) app.UI {
 						Board := n.AllBoards[i]
@@ -239,63 +755,325 @@ func  (n *newgamepage) Render() app.UI {
 						if !Board.Hidden && (len(n.BoardInput) == 0 || strings.Index(text, n.BoardInput) >= 0) {
 							return app.Li().Body(
 								app.Button().Text(text).
-									DataSet("board", i).OnClick(n.onSetBoard))
+									DataSet("board", Board.ID).OnClick(n.onSetBoard))
 						}
 						return app.Text("")
 					})),
 			),
 		),
-		app.H3().Text("Players:"),
-		app.Div().Body(
-			app.Range(n.Players).Slice(func(i int) app.UI {
-				return app.Stack().Content(
-					app.Button().Text("-").DataSet("player", i).OnClick(n.onDelPlayer),
-					app.Text(n.AllPlayers[n.Players[i]].Text),
-					app.Text(". Score:"),
-					app.Input().DataSet("player", i).OnInput(n.onSetScore))
+		app.If(n.assigning(),
+			app.H3().Text("Scores by position:"),
+			app.P().Text("This game was recorded by position in the player list, and that list was not kept. " +
+				"Pick the player of each score; until they are all picked the game keeps its scores as they are."),
+			app.Range(n.positions()).Slice(func(i int) app.UI {
+				Position := n.positions()[i]
+				Assigned, ok := n.Assigned[Position]
+				return app.Div().Body(
+					app.Text(fmt.Sprintf("#%v: %v ", Position + 1, formatScore(n.Positional[Position]))),
+					app.Select().DataSet("position", Position).OnChange(n.onAssign).Body(
+						app.Option().Value(-1).Text("Select player").Selected(!ok),
+						app.Range(n.AllPlayers).Slice(func(p int) app.UI {
+							Player := n.AllPlayers[p]
+							if ok && Assigned == Player.ID {
+								return app.Option().Value(Player.ID).Text(Player.Text).Selected(true)
+							}
+							if Player.Hidden || n.isAssigned(Player.ID) {
+								return app.Text("")
+							}
+							return app.Option().Value(Player.ID).Text(Player.Text)
+						}),
+					),
+				)
 			}),
 			app.Button().Text("RECORD GAME").OnClick(n.onSave),
 			app.Button().Text("Cancel").OnClick(n.onCancel),
-		),
-		app.H3().Text("Add players:"),
-		app.Div().Body(
-			app.Input().OnInput(n.onPlayerChange),
-			app.Button().Text("New").OnClick(n.onNewPlayer).Disabled(len(n.PlayerInput) == 0),
-			app.Ul().Body(
-				app.Range(n.AllPlayers).Slice(func(i int) app.UI {
-					Player := n.AllPlayers[i]
-					text := n.AllPlayers[i].Text
-					if !Player.Hidden && (len(n.PlayerInput) == 0 || strings.Index(text, n.PlayerInput) >= 0) {
-						return app.Li().Body(
-							app.Button().Text("+ " + text).
-								DataSet("player", i).OnClick(n.onAddPlayer))
-					}
-					return app.Text("")
-				})),
+		).Else(
+			app.H3().Text("Players:"),
+			app.Div().Body(
+				app.Range(n.Players).Slice(func(i int) app.UI {
+					ID := n.Players[i]
+					Team := stats.TeamOf(n.Game, ID)
+					return app.Stack().Content(
+						app.Button().Text("-").DataSet("player", ID).OnClick(n.onDelPlayer),
+						app.Button().Text("↑").DataSet("player", ID).Disabled(i == 0).OnClick(n.onMoveUp),
+						app.Button().Text("↓").DataSet("player", ID).Disabled(i == len(n.Players) - 1).OnClick(n.onMoveDown),
+						app.Text(findPlayer(n.AllPlayers, ID).Text),
+						app.If(len(Board.Factions) > 0,
+							app.Text(" as "),
+							app.Select().DataSet("player", ID).OnChange(n.onFaction).Body(
+								app.Option().Value("").Text("no faction").Selected(len(n.Game.Factions[ID]) == 0),
+								app.Range(Board.Factions).Slice(func(f int) app.UI {
+									return app.Option().Value(Board.Factions[f]).Text(Board.Factions[f]).
+										Selected(n.Game.Factions[ID] == Board.Factions[f])
+								}),
+							),
+						),
+						app.If(n.TeamPlay,
+							app.Text(" in "),
+							app.Select().DataSet("player", ID).OnChange(n.onSetTeam).Body(
+								app.Option().Value(-1).Text("no team").Selected(Team < 0),
+								app.Range(n.Game.Teams).Slice(func(t int) app.UI {
+									return app.Option().Value(t).Text(n.Game.Teams[t].Name).Selected(Team == t)
+								}),
+							),
+						),
+						app.If(Team < 0 && len(Categories) > 0,
+							app.Text(". Total: " + formatScore(n.Scores[ID])),
+						).ElseIf(Team < 0,
+							app.Text(scoreLabel),
+							n.scoreInput(scoreKey("player", ID),
+								app.Input().DataSet("player", ID).Value(scoreValue(n.Scores, ID)).OnInput(n.onSetScore)),
+						))
+				}),
+				app.If(len(Categories) > 0 && len(Solo) > 0,
+					app.Table().Body(
+						app.Tr().Body(
+							app.Th(),
+							app.Range(Categories).Slice(func(c int) app.UI {
+								return app.Th().Text(Categories[c])
+							}),
+							app.Th().Text("Total"),
+						),
+						app.Range(Solo).Slice(func(i int) app.UI {
+							ID := Solo[i]
+							return app.Tr().Body(
+								app.Td().Text(findPlayer(n.AllPlayers, ID).Text),
+								app.Range(Categories).Slice(func(c int) app.UI {
+									return app.Td().Body(
+										n.scoreInput(scoreKey("sheet", ID, c), app.Input().Size(4).
+											DataSet("player", ID).DataSet("category", c).
+											Value(sheetValue(n.Breakdown[ID], Categories[c])).
+											OnInput(n.onSheetScore)),
+									)
+								}),
+								app.Td().Text(formatScore(n.Scores[ID])),
+							)
+						}),
+					),
+				),
+				app.Div().Body(
+					app.Input().Type("checkbox").Checked(n.Game.SeatOrder).OnChange(n.onSeatOrder),
+					app.Text("Players are listed in turn order "),
+					app.Button().Text("Random first player").Disabled(len(n.Players) == 0).OnClick(n.onRandomFirst),
+					app.If(len(n.FirstPick) > 0,
+						app.Text(" " + n.FirstPick + " goes first!"),
+					),
+				),
+				app.Div().Body(
+					app.Input().Type("checkbox").Checked(n.TeamPlay).OnChange(n.onTeamPlay),
+					app.Text("Play in teams"),
+				),
+				app.If(n.TeamPlay,
+					app.Range(n.Game.Teams).Slice(func(t int) app.UI {
+						return app.Stack().Content(
+							app.Input().DataSet("team", t).Value(n.Game.Teams[t].Name).OnChange(n.onTeamName),
+							app.Text(scoreLabel),
+							n.scoreInput(scoreKey("team", t),
+								app.Input().DataSet("team", t).Value(formatScore(n.Game.Teams[t].Score)).OnInput(n.onTeamScore)),
+						)
+					}),
+					app.Button().Text("Add team").OnClick(n.onAddTeam),
+				),
+				app.If(Board.Scoring == stats.Cooperative,
+					app.Div().Body(
+						app.Input().Type("checkbox").Checked(n.Game.Won).OnChange(n.onWon),
+						app.Text("We won"),
+					),
+				),
+				app.Div().Body(
+					app.Text("Started at "),
+					app.Input().Type("time").Value(clockValue(n.Game.Start)).OnChange(n.onStart),
+					app.Text(" ended at "),
+					app.Input().Type("time").Value(clockValue(n.Game.End)).OnChange(n.onEnd),
+					app.If(!n.Editing && n.Game.Start == 0,
+						app.Text(" "),
+						app.Button().Text("Start timer").Disabled(len(n.Players) == 0).OnClick(n.onStartTimer),
+					).ElseIf(n.running(),
+						app.Text(" playing for " + runningClock(time.Since(time.Unix(n.Game.Start, 0)))),
+					).ElseIf(stats.GameDuration(n.Game) > 0,
+						app.Text(" played for " + formatDuration(stats.GameDuration(n.Game))),
+					),
+				),
+				app.If(len(n.Invalid) > 0,
+					app.P().Text("Some scores are not numbers."),
+				),
+				app.Button().Text("RECORD GAME").Disabled(len(n.Invalid) > 0).OnClick(n.onSave),
+				app.Button().Text("Cancel").OnClick(n.onCancel),
+			),
+			app.H3().Text("Add players:"),
+			app.Div().Body(
+				app.Input().OnInput(n.onPlayerChange),
+				app.If(len(n.PlayerSuggestions) > 0,
+					app.Text("Did you mean "),
+					app.Range(n.PlayerSuggestions).Slice(func(i int) app.UI {
+						return app.Button().Text(n.PlayerSuggestions[i].Text + "?").
+							DataSet("player", n.PlayerSuggestions[i].ID).OnClick(n.onAddPlayer)
+					}),
+					app.Button().Text("No, create " + n.PlayerInput).OnClick(n.onNewPlayer),
+				).Else(
+					app.Button().Text("New").OnClick(n.onNewPlayer).Disabled(len(n.PlayerInput) == 0),
+				),
+				app.Ul().Body(
+					app.Range(n.AllPlayers).Slice(func(i int) app.UI {
+						Player := n.AllPlayers[i]
+						text := n.AllPlayers[i].Text
+						if !Player.Hidden && (len(n.PlayerInput) == 0 || strings.Index(text, n.PlayerInput) >= 0) {
+							return app.Li().Body(
+								app.Button().Text("+ " + text).
+									DataSet("player", Player.ID).OnClick(n.onAddPlayer))
+						}
+						return app.Text("")
+					})),
+			),
 		),
 	)
 }
 
+// assigning tells whether the players of a game with positional scores are
+// still to be picked.
+func (n *newgamepage) assigning() bool {
+	return len(n.Assigned) < len(n.Positional)
+}
+
+// positions lists the positions of the scores to assign, in order.
+func (n *newgamepage) positions() []int {
+	Positions := make([]int, 0, len(n.Positional))
+	for Position := range n.Positional {
+		Positions = append(Positions, Position)
+	}
+	sort.Ints(Positions)
+	return Positions
+}
+
+func (n *newgamepage) isAssigned(Player int) bool {
+	for _, other := range n.Assigned {
+		if other == Player {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *newgamepage) onAssign(ctx app.Context, e app.Event) {
+	Position, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("position").String())
+	if err != nil {
+		app.Log("%s", "Unknown position for onAssign")
+		return
+	}
+	id, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onAssign")
+		return
+	}
+	if id < 0 {
+		delete(n.Assigned, Position)
+	} else {
+		n.Assigned[Position] = id
+	}
+	if !n.assigning() {
+		// every score has its player: they become the players of the game
+		n.Players = make([]int, 0, len(n.Positional))
+		for _, Position := range n.positions() {
+			Player := n.Assigned[Position]
+			n.Players = append(n.Players, Player)
+			n.Scores[Player] = n.Positional[Position]
+		}
+	}
+	n.Update()
+}
+
+// scoreValue is the text for a score input, empty when there is no score yet.
+func scoreValue(Scores map[int]float32, Player int) string {
+	Score, ok := Scores[Player]
+	if !ok {
+		return ""
+	}
+	return formatScore(Score)
+}
+
+// sheetValue is scoreValue for a category of a score sheet.
+func sheetValue(Sheet map[string]float32, Category string) string {
+	Score, ok := Sheet[Category]
+	if !ok {
+		return ""
+	}
+	return formatScore(Score)
+}
+
+func formatScore(Score float32) string {
+	return strconv.FormatFloat(float64(Score), 'g', -1, 32)
+}
+
+// parseScore reads a typed score, an empty one being 0. ParseFloat accepts
+// "NaN", "Inf" and numbers out of the float32 range, which cannot be stored
+// as JSON nor ranked: they are not scores.
+func parseScore(value string) (float32, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, true
+	}
+	score, err := strconv.ParseFloat(value, 32)
+	if err != nil || !finiteScore(float32(score)) {
+		return 0, false
+	}
+	return float32(score), true
+}
+
+func finiteScore(Score float32) bool {
+	return !math.IsNaN(float64(Score)) && !math.IsInf(float64(Score), 0)
+}
+
+// scoreKey names a score input: scoreKey("player", ID), scoreKey("team", t)
+// or scoreKey("sheet", ID, c).
+func scoreKey(kind string, IDs ...int) string {
+	key := kind
+	for _, ID := range IDs {
+		key += fmt.Sprintf("-%v", ID)
+	}
+	return key
+}
+
+// markScore remembers whether the score input holds a score.
+func (n *newgamepage) markScore(key string, ok bool) {
+	if ok {
+		delete(n.Invalid, key)
+	} else {
+		n.Invalid[key] = true
+	}
+}
+
+// scoreInput flags an input whose text is not a score.
+func (n *newgamepage) scoreInput(key string, Input app.HTMLInput) app.HTMLInput {
+	if !n.Invalid[key] {
+		return Input
+	}
+	return Input.Aria("invalid", true).Style("outline", "2px solid red").Title("not a number")
+}
+
 func (n *newgamepage) onBoardChange(ctx app.Context, e app.Event) {
 	n.BoardInput = ctx.JSSrc.Get("value").String()
+	n.BoardSuggestions = similarBoards(n.BoardInput, n.AllBoards)
 	n.Update()
 }
 
 func (n *newgamepage) onPlayerChange(ctx app.Context, e app.Event) {
 	n.PlayerInput = ctx.JSSrc.Get("value").String()
+	n.PlayerSuggestions = similarPlayers(n.PlayerInput, n.AllPlayers)
 	n.Update()
 }
 
 func (n *newgamepage) onNewBoard(ctx app.Context, e app.Event) {
-	Board, err := newBoard(n.BoardInput)
+	Board, err := newBoard(n.Store, n.BoardInput)
 	if err != nil {
 		app.Log("%s", errors.New("error creating new board").Wrap(err))
 		return
 	}
 	n.Board = Board.ID
+	n.Game.Expansions = nil
 	n.AllBoards = append(n.AllBoards, Board)
 	n.HasBoard = true
 	n.BoardInput = ""
+	n.BoardSuggestions = nil
 	n.Update()
 }
 
@@ -305,30 +1083,407 @@ func (n *newgamepage) onSetBoard(ctx app.Context, e app.Event) {
 		app.Log("%s", "Unknown board for onSetBoard")
 	}
 	n.Board = i
+	n.Game.Expansions = nil
+	// an expansion is recorded as played with its base game
+	if Board := findBoard(n.AllBoards, i); Board.HasBase {
+		n.Board = Board.Base
+		n.Game.Expansions = []int{Board.ID}
+	}
 	n.HasBoard = true
+	// the score sheet is the one of the new board
+	for key := range n.Invalid {
+		if strings.HasPrefix(key, "sheet-") {
+			delete(n.Invalid, key)
+		}
+	}
 	n.Update()
 }
 
-func (n *newgamepage) onSetScore(ctx app.Context, e app.Event) {
-	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+func (n *newgamepage) onSeatOrder(ctx app.Context, e app.Event) {
+	n.Game.SeatOrder = ctx.JSSrc.Get("checked").Bool()
+	n.Update()
+}
+
+// movePlayer moves a player by delta places in the turn order.
+func (n *newgamepage) movePlayer(ctx app.Context, delta int) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
 	if err != nil {
-		app.Log("%s", "Unknown player for onSetScore")
+		app.Log("%s", "Unknown player for movePlayer")
+		return
 	}
-	score, err := strconv.ParseFloat(ctx.JSSrc.Get("value").String(), 32)
-	if err != nil {
-		score = 0
+	for pos, Player := range n.Players {
+		if Player != id {
+			continue
+		}
+		if other := pos + delta; other >= 0 && other < len(n.Players) {
+			n.Players[pos], n.Players[other] = n.Players[other], n.Players[pos]
+			n.Game.SeatOrder = true
+		}
+		break
 	}
-	n.Scores[i] = float32(score)
 	n.Update()
 }
 
-func (n *newgamepage) onCancel(ctx app.Context, e app.Event) {
+func (n *newgamepage) onMoveUp(ctx app.Context, e app.Event) {
+	n.movePlayer(ctx, -1)
+}
+
+func (n *newgamepage) onMoveDown(ctx app.Context, e app.Event) {
+	n.movePlayer(ctx, 1)
+}
+
+// onRandomFirst picks the first player at random and turns the table so play
+// starts with them, keeping everyone in their seat.
+func (n *newgamepage) onRandomFirst(ctx app.Context, e app.Event) {
+	if len(n.Players) == 0 {
+		return
+	}
+	first := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(n.Players))
+	Players := make([]int, 0, len(n.Players))
+	Players = append(Players, n.Players[first:]...)
+	n.Players = append(Players, n.Players[:first]...)
+	n.Game.SeatOrder = true
+	n.FirstPick = findPlayer(n.AllPlayers, n.Players[0]).Text
+	n.startTimer()
+	n.Update()
+}
+
+func (n *newgamepage) onStartTimer(ctx app.Context, e app.Event) {
+	n.startTimer()
+	n.Update()
+}
+
+func (n *newgamepage) onFaction(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onFaction")
+		return
+	}
+	if n.Game.Factions == nil {
+		n.Game.Factions = map[int]string{}
+	}
+	n.Game.Factions[id] = ctx.JSSrc.Get("value").String()
+	if len(n.Game.Factions[id]) == 0 {
+		delete(n.Game.Factions, id)
+	}
+	n.Update()
+}
+
+func (n *newgamepage) onExpansion(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onExpansion")
+		return
+	}
+	Expansions := make([]int, 0, len(n.Game.Expansions) + 1)
+	for _, Expansion := range n.Game.Expansions {
+		if Expansion != id {
+			Expansions = append(Expansions, Expansion)
+		}
+	}
+	if ctx.JSSrc.Get("checked").Bool() {
+		Expansions = append(Expansions, id)
+	}
+	n.Game.Expansions = Expansions
+	n.Update()
+}
+
+// scoringSelect is a drop-down of the scoring modes, with m selected.
+func scoringSelect(m stats.ScoringMode, h app.EventHandler) app.HTMLSelect {
+	return app.Select().OnChange(h).Body(
+		app.Range(stats.ScoringModes).Slice(func(i int) app.UI {
+			return app.Option().Value(int(stats.ScoringModes[i])).
+				Text(stats.ScoringModes[i].String()).
+				Selected(stats.ScoringModes[i] == m)
+		}),
+	)
+}
+
+// scoringValue reads the scoring mode picked in a scoringSelect.
+func scoringValue(ctx app.Context) (stats.ScoringMode, error) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil || i < 0 || i >= len(stats.ScoringModes) {
+		return stats.HighestWins, errors.New("unknown scoring mode")
+	}
+	return stats.ScoringMode(i), nil
+}
+
+func (n *newgamepage) onScoring(ctx app.Context, e app.Event) {
+	m, err := scoringValue(ctx)
+	if err != nil {
+		app.Log("%s", err)
+		return
+	}
+	for idx := range n.AllBoards {
+		if n.AllBoards[idx].ID == n.Board {
+			n.AllBoards[idx].Scoring = m
+			if err := storeBoard(n.Store, n.AllBoards[idx]); err != nil {
+				app.Log("%s", errors.New("error storing board").Wrap(err))
+			}
+		}
+	}
+	n.Update()
+}
+
+func (n *newgamepage) onTeamPlay(ctx app.Context, e app.Event) {
+	n.TeamPlay = ctx.JSSrc.Get("checked").Bool()
+	for t := range n.Game.Teams {
+		delete(n.Invalid, scoreKey("team", t))
+	}
+	n.Game.Teams = nil
+	if n.TeamPlay {
+		n.Game.Teams = []team{{Name: "Team 1"}, {Name: "Team 2"}}
+	}
+	n.Update()
+}
+
+func (n *newgamepage) onAddTeam(ctx app.Context, e app.Event) {
+	n.Game.Teams = append(n.Game.Teams, team{Name: fmt.Sprintf("Team %v", len(n.Game.Teams) + 1)})
+	n.Update()
+}
+
+func (n *newgamepage) onTeamName(ctx app.Context, e app.Event) {
+	t, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("team").String())
+	if err != nil || t >= len(n.Game.Teams) {
+		app.Log("%s", "Unknown team for onTeamName")
+		return
+	}
+	if Name := strings.TrimSpace(ctx.JSSrc.Get("value").String()); len(Name) > 0 {
+		n.Game.Teams[t].Name = Name
+	}
+	n.Update()
+}
+
+func (n *newgamepage) onTeamScore(ctx app.Context, e app.Event) {
+	t, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("team").String())
+	if err != nil || t >= len(n.Game.Teams) {
+		app.Log("%s", "Unknown team for onTeamScore")
+		return
+	}
+	Score, ok := parseScore(ctx.JSSrc.Get("value").String())
+	n.markScore(scoreKey("team", t), ok)
+	if ok {
+		n.Game.Teams[t].Score = Score
+	}
+	n.Update()
+}
+
+// leaveTeam takes the player out of whatever team it is in.
+func (n *newgamepage) leaveTeam(Player int) {
+	for t := range n.Game.Teams {
+		Members := make([]int, 0, len(n.Game.Teams[t].Players))
+		for _, Member := range n.Game.Teams[t].Players {
+			if Member != Player {
+				Members = append(Members, Member)
+			}
+		}
+		n.Game.Teams[t].Players = Members
+	}
+}
+
+func (n *newgamepage) onSetTeam(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onSetTeam")
+		return
+	}
+	t, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil || t >= len(n.Game.Teams) {
+		app.Log("%s", "Unknown team for onSetTeam")
+		return
+	}
+	n.leaveTeam(id)
+	if t >= 0 {
+		n.Game.Teams[t].Players = append(n.Game.Teams[t].Players, id)
+	}
+	n.Update()
+}
+
+func (n *newgamepage) onWon(ctx app.Context, e app.Event) {
+	n.Game.Won = ctx.JSSrc.Get("checked").Bool()
+	n.Update()
+}
+
+func (n *newgamepage) onChangeBoard(ctx app.Context, e app.Event) {
+	n.HasBoard = false
+	n.Update()
+}
+
+func (n *newgamepage) onSetScore(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onSetScore")
+		return
+	}
+	Score, ok := parseScore(ctx.JSSrc.Get("value").String())
+	n.markScore(scoreKey("player", id), ok)
+	if ok {
+		n.Scores[id] = Score
+	}
+	n.Update()
+}
+
+// categories of the score sheet: the ones the game was recorded with when
+// editing it, otherwise the ones of the board.
+func (n *newgamepage) categories() []string {
+	if n.Editing && n.Game.Board == n.Board && len(n.Game.Categories) > 0 {
+		return n.Game.Categories
+	}
+	return findBoard(n.AllBoards, n.Board).Categories
+}
+
+func (n *newgamepage) onSheetScore(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onSheetScore")
+		return
+	}
+	Categories := n.categories()
+	c, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("category").String())
+	if err != nil || c < 0 || c >= len(Categories) {
+		app.Log("%s", "Unknown category for onSheetScore")
+		return
+	}
+	Score, ok := parseScore(ctx.JSSrc.Get("value").String())
+	Sheet := map[string]float32{}
+	for Category, Value := range n.Breakdown[id] {
+		Sheet[Category] = Value
+	}
+	Sheet[Categories[c]] = Score
+	// finite categories can still add up past the float32 range
+	Total := sheetTotal(Sheet, Categories)
+	ok = ok && finiteScore(Total)
+	n.markScore(scoreKey("sheet", id, c), ok)
+	if ok {
+		n.Breakdown[id] = Sheet
+		n.Scores[id] = Total
+	}
+	n.Update()
+}
+
+// clockValue is the text for a time input, empty for an unknown time.
+func clockValue(Unix int64) string {
+	if Unix == 0 {
+		return ""
+	}
+	return time.Unix(Unix, 0).Format("15:04")
+}
+
+// runningClock shows the time elapsed on the timer, as in "1:05:09".
+func runningClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d / time.Hour), int(d % time.Hour / time.Minute), int(d % time.Minute / time.Second))
+}
+
+// gameClock reads a time input into a unix time on the day of base. A game
+// ending earlier in the day than it started went past midnight.
+func gameClock(base int64, value string, after int64) (int64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	if base == 0 {
+		base = time.Now().Unix()
+	}
+	Unix, err := sessionTime(base, "15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	if after > 0 && Unix < after {
+		Unix += int64((24 * time.Hour) / time.Second)
+	}
+	return Unix, nil
+}
+
+func (n *newgamepage) onStart(ctx app.Context, e app.Event) {
+	base := n.Game.Start
+	if base == 0 {
+		SessionID := n.Game.Session
+		if !n.Editing {
+			SessionID = n.SessionID
+		}
+		Session, err := retrieveSession(n.Store, SessionID)
+		if err != nil {
+			app.Log("%s", errors.New("error fetching session").Wrap(err))
+			return
+		}
+		base = Session.Date
+	}
+	Start, err := gameClock(base, ctx.JSSrc.Get("value").String(), 0)
+	if err != nil {
+		app.Log("%s", err)
+		return
+	}
+	n.Game.Start = Start
+	n.Update()
+}
+
+func (n *newgamepage) onEnd(ctx app.Context, e app.Event) {
+	End, err := gameClock(n.Game.Start, ctx.JSSrc.Get("value").String(), n.Game.Start)
+	if err != nil {
+		app.Log("%s", err)
+		return
+	}
+	n.Game.End = End
+	n.Update()
+}
+
+func (n *newgamepage) onCancel(ctx app.Context, e app.Event) {
 	n.Full.Section = SSession
+	if n.Editing {
+		n.Full.Section = SGame
+	}
 	n.Full.Update()	
 }
 
 func (n *newgamepage) onSave(ctx app.Context, e app.Event) {
-	_, err := newGame(n.Board, n.SessionID, n.Scores)
+	if len(n.Invalid) > 0 {
+		return
+	}
+	n.Game.Board = n.Board
+	n.Game.Players = n.Players
+	Teams := make([]team, 0, len(n.Game.Teams))
+	for _, Team := range n.Game.Teams {
+		if len(Team.Players) > 0 {
+			Teams = append(Teams, Team)
+		}
+	}
+	n.Game.Teams = Teams
+	Factions := map[int]string{}
+	for _, Player := range n.Players {
+		if Faction, ok := n.Game.Factions[Player]; ok {
+			Factions[Player] = Faction
+		}
+	}
+	n.Game.Factions = nil
+	if len(Factions) > 0 && len(findBoard(n.AllBoards, n.Board).Factions) > 0 {
+		n.Game.Factions = Factions
+	}
+	Categories := n.categories()
+	n.Game.Categories = nil
+	n.Game.Breakdown = nil
+	if len(Categories) > 0 {
+		n.Game.Categories = Categories
+		n.Game.Breakdown = map[int]map[string]float32{}
+		for _, Player := range n.Players {
+			if Sheet, ok := n.Breakdown[Player]; ok && stats.TeamOf(n.Game, Player) < 0 {
+				n.Game.Breakdown[Player] = Sheet
+			}
+		}
+	}
+	if n.Editing {
+		if _, err := updateGame(n.Store, n.Game, n.Scores); err != nil {
+			app.Log("%s", errors.New("error updating game").Wrap(err))
+			return
+		}
+		n.Full.Section = SGame
+		n.Full.Update()
+		return
+	}
+	n.Game.Session = n.SessionID
+	if n.running() {
+		n.Game.End = time.Now().Unix()
+	}
+	_, err := newGame(n.Store, n.Game, n.Scores)
 	if err != nil {
 		app.Log("%s", errors.New("error creating new game").Wrap(err))
 		return
@@ -338,14 +1493,16 @@ func (n *newgamepage) onSave(ctx app.Context, e app.Event) {
 }
 
 func (n *newgamepage) onNewPlayer(ctx app.Context, e app.Event) {
-	Player, err := newPlayer(n.PlayerInput)
+	Player, err := newPlayer(n.Store, n.PlayerInput)
 	if err != nil {
 		app.Log("%s", errors.New("error creating new player").Wrap(err))
 		return
 	}
 	n.Players = append(n.Players, Player.ID)
+	n.startTimer()
 	n.AllPlayers = append(n.AllPlayers, Player)
 	n.PlayerInput = ""
+	n.PlayerSuggestions = nil
 	n.Update()
 }
 
@@ -353,9 +1510,15 @@ func (n *newgamepage) onAddPlayer(ctx app.Context, e app.Event) {
 	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
 	if err != nil {
 		app.Log("%s", "Unknown player for onAddPlayer")
+		return
+	}
+	for _, other := range n.Players {
+		if other == id {
+			return
+		}
 	}
-	
 	n.Players = append(n.Players, id)
+	n.startTimer()
 	n.Update()
 }
 
@@ -363,6 +1526,7 @@ func (n *newgamepage) onDelPlayer(ctx app.Context, e app.Event) {
 	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
 	if err != nil {
 		app.Log("%s", "Unknown player for onDelPlayer")
+		return
 	}
 	found := -1
 	for pos, other := range n.Players {
@@ -372,6 +1536,16 @@ func (n *newgamepage) onDelPlayer(ctx app.Context, e app.Event) {
 	}
 	if found >= 0 {
 		n.Players = append(n.Players[:found], n.Players[found+1:]...)
+		delete(n.Scores, id)
+		delete(n.Breakdown, id)
+		delete(n.Game.Factions, id)
+		n.leaveTeam(id)
+		delete(n.Invalid, scoreKey("player", id))
+		for key := range n.Invalid {
+			if strings.HasPrefix(key, scoreKey("sheet", id) + "-") {
+				delete(n.Invalid, key)
+			}
+		}
 		n.Update()
 	}
 }
@@ -381,93 +1555,358 @@ type downloadpage struct {
 	app.Compo
 
 	Full *fullpage
+	Store Store
 	Ready bool
 	Data string
+	Filename string
+	URL string
+	Status string
 }
 
 func (d *downloadpage) OnMount(ctx app.Context) {
 	go d.prepareData()
 }
 
+func (d *downloadpage) OnDismount() {
+	if len(d.URL) > 0 {
+		app.Window().Get("URL").Call("revokeObjectURL", d.URL)
+	}
+}
+
 func (d *downloadpage) Render() app.UI {
 	if !d.Ready {
 		return app.Text("Preparing your download...")
 	}
-	return app.Pre().Text(d.Data)
-	// problem with the router and pushstate
-	//return app.A().Text("download").Download(true).Href(d.Data)
+	return app.Div().Body(
+		app.H2().Text("Download"),
+		app.P().Text("Your logbook as " + d.Filename),
+		app.Button().Text("Download").OnClick(d.onDownload),
+		app.Button().Text("Copy to clipboard").OnClick(d.onCopy),
+		app.If(len(d.Status) > 0, app.P().Text(d.Status)),
+		app.Div().Body(
+			app.Textarea().ID("download-data").ReadOnly(true).Rows(20).Cols(80).Text(d.Data),
+		),
+		app.Button().Text("close").OnClick(d.onClose),
+	)
 }
 
 func (d *downloadpage) prepareData() {
-	data := make(map[string] interface{})
-	var err error
-	data["sessions"], err = retrieveAllSessions()
-	if err != nil {
-		app.Log("%s", errors.New("error preparing data").Wrap(err))
-	}
-	data["boards"], err = retrieveAllBoards()
-	if err != nil {
-		app.Log("%s", errors.New("error preparing data").Wrap(err))
-	}
-	data["players"], err = retrieveAllPlayers()
+	data, err := exportLogbook(d.Store)
 	if err != nil {
 		app.Log("%s", errors.New("error preparing data").Wrap(err))
 	}
-	//DataBytes, err := json.Marshal(data)
 	DataBytes, err := json.MarshalIndent(data, "", "  ")	
 	if err != nil {
 		app.Log("%s", errors.New("error preparing data").Wrap(err))
 	}
-	// not working yet
-	//Data := "data:text/plain;charset=utf-8," + url.QueryEscape(string(DataBytes))
 	Data := string(DataBytes)
+	Filename := exportFilename(time.Now())
 	app.Dispatch(func() { // Ensures update is on UI goroutine.
 		d.Data = Data
+		d.Filename = Filename
 		d.Ready = true
 		d.Update()
 	})
 }
 
+// onDownload clicks an anchor that is never attached to the document, so the
+// go-app router (listening for clicks on the window) does not turn the blob
+// URL into a pushstate navigation.
+func (d *downloadpage) onDownload(ctx app.Context, e app.Event) {
+	if len(d.URL) == 0 {
+		Blob := app.Window().Get("Blob").New([]interface{}{d.Data}, map[string]interface{}{
+			"type": "application/json",
+		})
+		d.URL = app.Window().Get("URL").Call("createObjectURL", Blob).String()
+	}
+	Anchor := app.Window().Get("document").Call("createElement", "a")
+	Anchor.Set("href", d.URL)
+	Anchor.Set("download", d.Filename)
+	Anchor.Set("target", "_blank")
+	Anchor.Call("click")
+}
+
+// onCopy is the fallback for mobile browsers that ignore downloads.
+func (d *downloadpage) onCopy(ctx app.Context, e app.Event) {
+	Clipboard := app.Window().Get("navigator").Get("clipboard")
+	if !Clipboard.Truthy() {
+		d.selectData("Clipboard not available, the text is selected for copying.")
+		return
+	}
+	var onDone, onFail app.Func
+	release := func() {
+		onDone.Release()
+		onFail.Release()
+	}
+	onDone = app.FuncOf(func(this app.Value, args []app.Value) interface{} {
+		release()
+		app.Dispatch(func() {
+			d.Status = "Copied to clipboard."
+			d.Update()
+		})
+		return nil
+	})
+	onFail = app.FuncOf(func(this app.Value, args []app.Value) interface{} {
+		release()
+		app.Dispatch(func() {
+			d.selectData("Copying failed, the text is selected for copying.")
+		})
+		return nil
+	})
+	Clipboard.Call("writeText", d.Data).Call("then", onDone, onFail)
+}
+
+func (d *downloadpage) selectData(status string) {
+	if Data := app.Window().GetElementByID("download-data"); Data.Truthy() {
+		Data.Call("select")
+	}
+	d.Status = status
+	d.Update()
+}
+
+func (d *downloadpage) onClose(ctx app.Context, e app.Event) {
+	d.Full.Section = SMenu
+	d.Full.Update()
+}
+
+
+type importMode int
+
+const (
+	IChoose importMode = iota
+	IReplace
+	IMerge
+	IDone
+)
+
+type importpage struct {
+	app.Compo
+
+	Full *fullpage
+	Store Store
+	Loaded bool
+	Error string
+	// why planning or writing the import failed
+	Failure string
+	Doc exportDoc
+	Games int
+	Mode importMode
+	Plan mergePlan
+	Sessions int
+	Players int
+	Boards int
+}
+
+func (i *importpage) OnMount(ctx app.Context) {
+	var err error
+	Sessions, err := retrieveAllSessions(i.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error fetching sessions").Wrap(err))
+		return
+	}
+	i.Sessions = len(Sessions)
+	if i.Players, err = getPlayerCount(i.Store); err != nil {
+		app.Log("%s", errors.New("error fetching player count").Wrap(err))
+		return
+	}
+	if i.Boards, err = getBoardCount(i.Store); err != nil {
+		app.Log("%s", errors.New("error fetching board count").Wrap(err))
+		return
+	}
+	i.Update()
+}
+
+func (i *importpage) Render() app.UI {
+	exported := time.Unix(i.Doc.Exported, 0)
+	return app.Div().Body(
+		app.H2().Text("Import"),
+		app.If(!i.Loaded,
+			app.P().Text("Choose a previously downloaded logbook file."),
+			app.Input().Type("file").Accept(".json,application/json").OnChange(i.onFile),
+		).ElseIf(i.Mode == IChoose,
+			app.P().Text(fmt.Sprintf("Logbook exported on %v with %v sessions, %v games, %v players and %v boards.",
+				exported.Format("2006-01-02"), len(i.Doc.Sessions), i.Games, len(i.Doc.Players), len(i.Doc.Boards))),
+			app.Button().Text("Replace everything").OnClick(i.onReplace),
+			app.Button().Text("Merge").OnClick(i.onMerge),
+		).ElseIf(i.Mode == IReplace,
+			app.P().Text(fmt.Sprintf("This deletes the current %v sessions, %v players and %v boards.", i.Sessions, i.Players, i.Boards)),
+			app.P().Text(fmt.Sprintf("It then writes %v sessions, %v games, %v players and %v boards from the file.",
+				len(i.Doc.Sessions), i.Games, len(i.Doc.Players), len(i.Doc.Boards))),
+			app.Button().Text("Confirm").OnClick(i.onConfirm),
+			app.Button().Text("Back").OnClick(i.onBack),
+		).ElseIf(i.Mode == IMerge,
+			app.Ul().Body(
+				app.Li().Text(fmt.Sprintf("%v new players, %v already known", len(i.Plan.NewPlayers), i.Plan.MatchedPlayers)),
+				app.Li().Text(fmt.Sprintf("%v new boards, %v already known", len(i.Plan.NewBoards), i.Plan.MatchedBoards)),
+				app.Li().Text(fmt.Sprintf("%v new locations", len(i.Plan.NewLocations))),
+				app.Li().Text(fmt.Sprintf("%v new sessions with %v games", i.Plan.NewSessions, i.Plan.NewGames)),
+				app.Li().Text(fmt.Sprintf("%v sessions already in the logbook, skipped", len(i.Plan.SkippedSessions))),
+			),
+			app.If(len(i.Plan.SkippedSessions) > 0,
+				app.P().Text("Skipped, the same games were found in a session of that day:"),
+				app.Ul().Body(
+					app.Range(i.Plan.SkippedSessions).Slice(func(j int) app.UI {
+						Session := i.Plan.SkippedSessions[j]
+						return app.Li().Text(fmt.Sprintf("%v, %v games",
+							time.Unix(Session.Date, 0).Format("2006-01-02 15:04"), len(Session.Games)))
+					}),
+				),
+			),
+			app.Button().Text("Confirm").OnClick(i.onConfirm),
+			app.Button().Text("Back").OnClick(i.onBack),
+		).Else(
+			app.P().Text("Import finished."),
+		),
+		app.If(len(i.Error) > 0, app.P().Text(i.Error)),
+		app.If(len(i.Failure) > 0, app.Pre().Text(i.Failure)),
+		app.Button().Text("close").OnClick(i.onClose),
+	)
+}
+
+func (i *importpage) onFile(ctx app.Context, e app.Event) {
+	files := ctx.JSSrc.Get("files")
+	if files.Length() == 0 {
+		return
+	}
+	var onText app.Func
+	onText = app.FuncOf(func(this app.Value, args []app.Value) interface{} {
+		Data := args[0].String()
+		onText.Release()
+		app.Dispatch(func() { // Ensures update is on UI goroutine.
+			i.load(Data)
+		})
+		return nil
+	})
+	files.Index(0).Call("text").Call("then", onText)
+}
+
+func (i *importpage) load(Data string) {
+	Doc, err := parseExport([]byte(Data))
+	if err != nil {
+		app.Log("%s", errors.New("error reading import").Wrap(err))
+		i.Error = "This file is not a logbook export."
+		i.Failure = ""
+		i.Update()
+		return
+	}
+	i.Doc = Doc
+	i.Games = 0
+	for _, Session := range Doc.Sessions {
+		i.Games += len(Session.Games)
+	}
+	i.Error = ""
+	i.Failure = ""
+	i.Loaded = true
+	i.Mode = IChoose
+	i.Update()
+}
+
+func (i *importpage) onReplace(ctx app.Context, e app.Event) {
+	i.Mode = IReplace
+	i.Update()
+}
+
+func (i *importpage) onMerge(ctx app.Context, e app.Event) {
+	var err error
+	if i.Plan, err = planMerge(i.Store, i.Doc); err != nil {
+		app.Log("%s", errors.New("error planning merge").Wrap(err))
+		i.fail("The merge could not be prepared, nothing was written.", err)
+		return
+	}
+	i.Mode = IMerge
+	i.Update()
+}
+
+func (i *importpage) onBack(ctx app.Context, e app.Event) {
+	i.Mode = IChoose
+	i.Error = ""
+	i.Failure = ""
+	i.Update()
+}
+
+func (i *importpage) onConfirm(ctx app.Context, e app.Event) {
+	var err error
+	if i.Mode == IReplace {
+		err = importReplace(i.Store, i.Doc)
+	} else {
+		err = applyMerge(i.Store, i.Plan)
+	}
+	if err != nil {
+		app.Log("%s", errors.New("error importing").Wrap(err))
+		i.fail("The import failed and was rolled back, the logbook is unchanged.", err)
+		return
+	}
+	i.Mode = IDone
+	i.Update()
+}
+
+// fail shows why the import stopped, staying on the current step.
+func (i *importpage) fail(Message string, err error) {
+	i.Error = Message
+	i.Failure = err.Error()
+	i.Update()
+}
+
+func (i *importpage) onClose(ctx app.Context, e app.Event) {
+	i.Full.Section = SMenu
+	i.Full.Update()
+}
+
 
 type gamepage struct {
 	app.Compo
 
 	Full *fullpage
+	Store Store
 	SessionID int
 	Session session
 	GameID int
 	Game game
 	Scores []score
 	Board board
+	Boards []board
 	Players map[int]player
+	Places []stats.Placement
+	ConfirmDelete bool
 }
 
 func (g *gamepage) OnMount(ctx app.Context) {
 	var err error
-	g.Session, err = retrieveSession(g.SessionID)
+	g.Session, err = retrieveSession(g.Store, g.SessionID)
 	if err != nil {
 		app.Log("%s", errors.New("error retrieving session").Wrap(err))
 		return
 	}
-	g.Game, err = retrieveGame(g.GameID)
+	g.Game, err = retrieveGame(g.Store, g.GameID)
 	if err != nil {
 		app.Log("%s", errors.New("error retrieving game").Wrap(err))
 		return
 	}
-	g.Scores, err = retrieveScoresInGame(g.Game.ID)
+	g.Scores, err = retrieveScoresInGame(g.Store, g.Game.ID)
 	if err != nil {
 		app.Log("%s", errors.New("error retrieving scores").Wrap(err))
 		return
 	}
-	g.Board, err = retrieveBoard(g.Game.Board)
+	g.Board, err = retrieveBoard(g.Store, g.Game.Board)
 	if err != nil {
 		app.Log("%s", errors.New("error retrieving board").Wrap(err))
 		return
 	}
-	g.Players = make(map[int]player, len(g.Scores))
-	for _, Score := range g.Scores {
-		g.Players[Score.Player], err = retrievePlayer(Score.Player)
-		if err != nil {
+	g.Boards, err = retrieveAllBoards(g.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error retrieving boards").Wrap(err))
+		return
+	}
+	ScoreMap, err := retrieveScoresInGameMap(g.Store, g.Game.ID)
+	if err != nil {
+		app.Log("%s", errors.New("error retrieving scores").Wrap(err))
+		return
+	}
+	g.Places = stats.Placements(g.Board.Scoring, g.Game, ScoreMap)
+	g.Players = make(map[int]player, len(g.Scores))
+	for _, Score := range g.Scores {
+		g.Players[Score.Player], err = retrievePlayer(g.Store, Score.Player)
+		if err != nil {
 			app.Log("%s", errors.New("error retrieving player").Wrap(err))
 			return
 		}
@@ -480,16 +1919,78 @@ func  (g *gamepage) Render() app.UI {
 
 	return app.Div().Body(
 		app.H2().Text("Session for "  +  theTime.Format("2006-01-02")),
-		app.H3().Text("Game of " + g.Board.Text),
+		app.H3().Text("Game of " + comboName(stats.ComboOf(g.Game), g.Boards)),
+		app.If(stats.GameDuration(g.Game) > 0,
+			app.P().Text(fmt.Sprintf("Played from %v to %v (%v).", clockValue(g.Game.Start), clockValue(g.Game.End), formatDuration(stats.GameDuration(g.Game)))),
+		),
+		app.If(g.Game.PositionalScores,
+			app.P().Text("These scores were recorded by position in the player list, so they may be attributed to the wrong players."),
+		),
+		app.If(g.Board.Scoring == stats.Cooperative && g.Game.Won,
+			app.P().Text("The group won."),
+		).ElseIf(g.Board.Scoring == stats.Cooperative,
+			app.P().Text("The group lost."),
+		),
 		app.Text("Players:"),
 		app.Ul().Body(
-			app.Range(g.Scores).Slice(func(i int) app.UI {
-				Player := g.Players[g.Scores[i].Player]
-				Score := g.Scores[i].Score
-				return app.Li().Body(
-					app.Text(fmt.Sprintf("%v: %v", Player.Text, Score)),
-				)
+			app.Range(g.Places).Slice(func(i int) app.UI {
+				Place := g.Places[i]
+				Player := g.Players[Place.Player]
+				if Faction, ok := g.Game.Factions[Place.Player]; ok {
+					Player.Text += " (" + Faction + ")"
+				}
+				switch g.Board.Scoring {
+				case stats.Cooperative:
+					return app.Li().Text(fmt.Sprintf("%v: %v", Player.Text, Place.Score))
+				case stats.RankOnly:
+					return app.Li().Text(fmt.Sprintf("%v. %v", Place.Place, Player.Text))
+				}
+				won := ""
+				if Place.Place == 1 {
+					won = " (winner)"
+				}
+				if len(Place.Team) > 0 {
+					return app.Li().Text(fmt.Sprintf("%v. %v of %v: %v%v", Place.Place, Player.Text, Place.Team, Place.Score, won))
+				}
+				return app.Li().Text(fmt.Sprintf("%v. %v: %v%v", Place.Place, Player.Text, Place.Score, won))
 			})),
+		app.If(len(g.Game.Categories) > 0 && len(g.Game.Breakdown) > 0,
+			app.Table().Body(
+				app.Tr().Body(
+					app.Th(),
+					app.Range(g.Game.Categories).Slice(func(c int) app.UI {
+						return app.Th().Text(g.Game.Categories[c])
+					}),
+					app.Th().Text("Total"),
+				),
+				app.Range(g.Places).Slice(func(i int) app.UI {
+					Place := g.Places[i]
+					Sheet, ok := g.Game.Breakdown[Place.Player]
+					if !ok {
+						return app.Text("")
+					}
+					return app.Tr().Body(
+						app.Td().Text(g.Players[Place.Player].Text),
+						app.Range(g.Game.Categories).Slice(func(c int) app.UI {
+							return app.Td().Text(sheetValue(Sheet, g.Game.Categories[c]))
+						}),
+						app.Td().Text(formatScore(Place.Score)),
+					)
+				}),
+			),
+		),
+		app.Div().Body(
+			app.Text("Notes:"),
+			app.Textarea().Rows(3).Cols(60).Text(g.Game.Notes).OnChange(g.onNotes),
+		),
+		app.Button().Text("edit").OnClick(g.onEdit),
+		app.If(g.ConfirmDelete,
+			app.Text("Delete this game for good?"),
+			app.Button().Text("yes, delete").OnClick(g.onDelete),
+			app.Button().Text("no").OnClick(g.onCancelDelete),
+		).Else(
+			app.Button().Text("delete").OnClick(g.onConfirmDelete),
+		),
 		app.Button().Text("close").OnClick(g.onClose),
 	)
 }
@@ -499,36 +2000,109 @@ func (g *gamepage) onClose(ctx app.Context, e app.Event) {
 	g.Full.Update()	
 }
 
+func (g *gamepage) onEdit(ctx app.Context, e app.Event) {
+	g.Full.Section = SEditGame
+	g.Full.Update()
+}
+
+func (g *gamepage) onNotes(ctx app.Context, e app.Event) {
+	g.Game.Notes = ctx.JSSrc.Get("value").String()
+	if err := storeGame(g.Store, g.Game); err != nil {
+		app.Log("%s", errors.New("error storing game notes").Wrap(err))
+		return
+	}
+	g.Update()
+}
+
+func (g *gamepage) onConfirmDelete(ctx app.Context, e app.Event) {
+	g.ConfirmDelete = true
+	g.Update()
+}
+
+func (g *gamepage) onCancelDelete(ctx app.Context, e app.Event) {
+	g.ConfirmDelete = false
+	g.Update()
+}
+
+func (g *gamepage) onDelete(ctx app.Context, e app.Event) {
+	if err := deleteGame(g.Store, g.Game.ID); err != nil {
+		app.Log("%s", errors.New("error deleting game").Wrap(err))
+		return
+	}
+	g.Full.Section = SSession
+	g.Full.Update()
+}
+
 
 type sessionspage struct {
 	app.Compo
 
 	Full *fullpage
+	Store Store
 	Sessions []session
+	Search string
+	Matches []noteMatch
+	Boards []board
 }
 
 func (s *sessionspage) OnMount(ctx app.Context) {
 	var err error
-	s.Sessions, err = retrieveAllSessions()
+	s.Sessions, err = retrieveAllSessions(s.Store)
 	if err != nil {
 		app.Log("%s", errors.New("error retrieving sessions").Wrap(err))
 		return
 	}
+	// most recent first
+	sort.SliceStable(s.Sessions, func(i, j int) bool {
+		return s.Sessions[i].Date > s.Sessions[j].Date
+	})
+	if s.Boards, err = retrieveAllBoards(s.Store); err != nil {
+		app.Log("%s", errors.New("error retrieving boards").Wrap(err))
+		return
+	}
 	s.Update()
 }
 
 func  (s *sessionspage) Render() app.UI {
-	totalLen := len(s.Sessions)
 	return app.Div().Body(
 		app.H2().Text("Sessions"),
-		app.Ul().Body(
+		app.Div().Body(
+			app.Text("Search notes: "),
+			app.Input().Value(s.Search).OnInput(s.onSearch),
+		),
+		app.If(len(strings.TrimSpace(s.Search)) > 0,
+			app.If(len(s.Matches) == 0,
+				app.P().Text("No notes found."),
+			),
+			app.Ul().Body(
+				app.Range(s.Matches).Slice(func(i int) app.UI {
+					Match := s.Matches[i]
+					title := "Session for " + time.Unix(Match.Session.Date, 0).Format("2006-01-02")
+					if Match.Game < 0 {
+						return app.Li().Body(
+							app.Button().Text(title).
+								DataSet("session", Match.Session.ID).
+								OnClick(s.onSession),
+							app.Text(" " + Match.Notes),
+						)
+					}
+					return app.Li().Body(
+						app.Button().Text(findBoard(s.Boards, Match.Board).Text + ", " + title).
+							DataSet("session", Match.Session.ID).
+							DataSet("game", Match.Game).
+							OnClick(s.onGame),
+						app.Text(" " + Match.Notes),
+					)
+				})),
+		).Else(app.Ul().Body(
 			app.Range(s.Sessions).Slice(func(i int) app.UI {
-				theTime := time.Unix(s.Sessions[totalLen - i - 1].Date, 0)
+				theTime := time.Unix(s.Sessions[i].Date, 0)
 				return app.Li().Body(
 					app.Button().Text("Session for "  +  theTime.Format("2006-01-02")).
-						DataSet("session", totalLen - i - 1).
+						DataSet("session", s.Sessions[i].ID).
 						OnClick(s.onSession))
 			})),
+		),
 		app.Button().Text("close").OnClick(s.onClose),
 	)
 }
@@ -545,6 +2119,34 @@ func (s *sessionspage) onSession(ctx app.Context, e app.Event) {
 	s.Full.Update()
 }
 
+func (s *sessionspage) onSearch(ctx app.Context, e app.Event) {
+	var err error
+	s.Search = ctx.JSSrc.Get("value").String()
+	if s.Matches, err = searchNotes(s.Store, s.Search); err != nil {
+		app.Log("%s", errors.New("error searching notes").Wrap(err))
+		return
+	}
+	s.Update()
+}
+
+func (s *sessionspage) onGame(ctx app.Context, e app.Event) {
+	Session, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("session").String())
+	if err != nil {
+		app.Log("%s", "Unknown session for onGame")
+		return
+	}
+	Game, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("game").String())
+	if err != nil {
+		app.Log("%s", "Unknown game for onGame")
+		return
+	}
+	s.Full.Session = Session
+	s.Full.Game = Game
+	s.Full.Section = SGame
+	s.Full.Previous = SSessions
+	s.Full.Update()
+}
+
 func (s *sessionspage) onClose(ctx app.Context, e app.Event) {
 	s.Full.Section = SMenu
 	s.Full.Update()	
@@ -554,16 +2156,40 @@ type playerspage struct {
 	app.Compo
 
 	Full *fullpage
+	Store Store
 	Players []player
+	// total time played, by player ID
+	TimePlayed map[int]time.Duration
+
+	// position in Players of the player being renamed or merged
+	Selected int
+	Renaming bool
+	Input string
+	Merging bool
+	MergeInto int
+	Affected int
+	Conflicts int
 }
 
 func (p *playerspage) OnMount(ctx app.Context) {
+	p.load()
+}
+
+func (p *playerspage) load() {
 	var err error
-	p.Players, err = retrieveAllPlayers()
+	p.Players, err = retrieveAllPlayers(p.Store)
 	if err != nil {
 		app.Log("%s", errors.New("error retrieving players").Wrap(err))
 		return
 	}
+	Games, err := retrieveAllGames(p.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error retrieving games").Wrap(err))
+		return
+	}
+	p.TimePlayed = stats.TimePlayed(Games)
+	p.Renaming = false
+	p.Merging = false
 	p.Update()
 }
 
@@ -578,24 +2204,153 @@ func  (p *playerspage) Render() app.UI {
 					show = "show"
 				}
 				return app.Li().Body(
-					app.Text(Player.Text),
+					app.If(p.Renaming && p.Selected == i,
+						app.Input().Value(p.Input).OnInput(p.onRenameInput),
+						app.Button().Text("save").
+							Disabled(len(strings.TrimSpace(p.Input)) == 0).
+							OnClick(p.onRename),
+						app.Button().Text("cancel").OnClick(p.onCancel),
+					).Else(
+						app.Button().Text(Player.Text).
+							DataSet("player", Player.ID).
+							OnClick(p.onProfile),
+					),
+					app.If(p.TimePlayed[Player.ID] > 0,
+						app.Text(" (" + formatDuration(p.TimePlayed[Player.ID]) + " played)"),
+					),
 					app.Button().Text(show).
 						DataSet("player", i).
 						OnClick(p.onToggle),
+					app.Button().Text("rename").
+						DataSet("player", i).
+						OnClick(p.onStartRename),
+					app.Button().Text("merge").
+						DataSet("player", i).
+						OnClick(p.onStartMerge),
 				)
 			})),
+		app.If(p.Merging,
+			app.Div().Body(
+				app.Text(fmt.Sprintf("Merge %v into ", p.Players[p.Selected].Text)),
+				app.Select().OnChange(p.onMergeInto).Body(
+					app.Option().Value(-1).Text("choose a player").Selected(p.MergeInto < 0),
+					app.Range(p.Players).Slice(func(i int) app.UI {
+						if i == p.Selected {
+							return app.Text("")
+						}
+						return app.Option().Value(i).Text(p.Players[i].Text).Selected(p.MergeInto == i)
+					}),
+				),
+				app.If(p.MergeInto >= 0 && p.Conflicts > 0,
+					app.P().Text(fmt.Sprintf("They played together in %v games, so they cannot be merged.", p.Conflicts)),
+				).ElseIf(p.MergeInto >= 0,
+					app.P().Text(fmt.Sprintf("%v games will be changed.", p.Affected)),
+				),
+				app.Button().Text("merge").
+					Disabled(p.MergeInto < 0 || p.Conflicts > 0).
+					OnClick(p.onMerge),
+				app.Button().Text("cancel").OnClick(p.onCancel),
+			),
+		),
 		app.Button().Text("close").OnClick(p.onClose),
 	)
 }
 
 
+func (p *playerspage) onProfile(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onProfile")
+		return
+	}
+	p.Full.Player = id
+	p.Full.Section = SPlayer
+	p.Full.Update()
+}
+
 func (p *playerspage) onToggle(ctx app.Context, e app.Event) {
 	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
 	if err != nil {
 		app.Log("%s", "Unknown player for onToggle")
 	}
 	p.Players[i].Hidden = !p.Players[i].Hidden
-	p.Players[i].store()
+	storePlayer(p.Store, p.Players[i])
+	p.Update()
+}
+
+func (p *playerspage) onStartRename(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onStartRename")
+		return
+	}
+	p.Selected = i
+	p.Input = p.Players[i].Text
+	p.Renaming = true
+	p.Merging = false
+	p.Update()
+}
+
+func (p *playerspage) onRenameInput(ctx app.Context, e app.Event) {
+	p.Input = ctx.JSSrc.Get("value").String()
+	p.Update()
+}
+
+func (p *playerspage) onRename(ctx app.Context, e app.Event) {
+	Player := p.Players[p.Selected]
+	Player.Text = strings.TrimSpace(p.Input)
+	if err := storePlayer(p.Store, Player); err != nil {
+		app.Log("%s", errors.New("error renaming player").Wrap(err))
+		return
+	}
+	p.Players[p.Selected] = Player
+	p.Renaming = false
+	p.Update()
+}
+
+func (p *playerspage) onStartMerge(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onStartMerge")
+		return
+	}
+	p.Selected = i
+	p.MergeInto = -1
+	p.Merging = true
+	p.Renaming = false
+	p.Update()
+}
+
+func (p *playerspage) onMergeInto(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onMergeInto")
+		return
+	}
+	p.MergeInto = i
+	if i >= 0 {
+		affected, conflicts, err := mergePreview(p.Store, "player", p.Players[i].ID, p.Players[p.Selected].ID)
+		if err != nil {
+			app.Log("%s", errors.New("error previewing merge").Wrap(err))
+			return
+		}
+		p.Affected = len(affected)
+		p.Conflicts = len(conflicts)
+	}
+	p.Update()
+}
+
+func (p *playerspage) onMerge(ctx app.Context, e app.Event) {
+	if err := mergePlayers(p.Store, p.Players[p.MergeInto].ID, p.Players[p.Selected].ID); err != nil {
+		app.Log("%s", errors.New("error merging players").Wrap(err))
+		return
+	}
+	p.load()
+}
+
+func (p *playerspage) onCancel(ctx app.Context, e app.Event) {
+	p.Renaming = false
+	p.Merging = false
 	p.Update()
 }
 
@@ -604,26 +2359,175 @@ func (p *playerspage) onClose(ctx app.Context, e app.Event) {
 	p.Full.Update()	
 }
 
+type playerpage struct {
+	app.Compo
+
+	Full *fullpage
+	Store Store
+	PlayerID int
+	Player player
+	Stats stats.PlayerStats
+	Boards map[int]board
+	TimePlayed time.Duration
+	// overall Elo rating
+	Rating rating
+	History []ratingPoint
+}
+
+func (p *playerpage) OnMount(ctx app.Context) {
+	var err error
+	if p.Player, err = retrievePlayer(p.Store, p.PlayerID); err != nil {
+		app.Log("%s", errors.New("error retrieving player").Wrap(err))
+		return
+	}
+	Log, err := loadLogbook(p.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error loading logbook").Wrap(err))
+		return
+	}
+	p.Stats = stats.ComputePlayerStats(Log, p.PlayerID)
+	p.Boards = Log.Boards
+	p.TimePlayed = stats.TimePlayed(Log.Games)[p.PlayerID]
+	Ratings := computeRatings(Log, Elo, -1)
+	p.Rating = Ratings.Ratings[p.PlayerID]
+	p.History = Ratings.History[p.PlayerID]
+	p.Update()
+}
+
+func  (p *playerpage) Render() app.UI {
+	Stats := p.Stats
+	return app.Div().Body(
+		app.H2().Text(p.Player.Text),
+		app.If(Stats.Games == 0,
+			app.P().Text("No games recorded yet."),
+		).Else(
+			app.Ul().Body(
+				app.Li().Text(fmt.Sprintf("Games: %v", Stats.Games)),
+				app.Li().Text(fmt.Sprintf("Sessions attended: %v", Stats.Sessions)),
+				app.Li().Text(fmt.Sprintf("Wins: %v (%.0f%%)", Stats.Wins, Stats.WinRate)),
+				app.If(Stats.AveragePlace > 0,
+					app.Li().Text(fmt.Sprintf("Average place: %.1f", Stats.AveragePlace)),
+				),
+				app.If(p.TimePlayed > 0,
+					app.Li().Text("Time played: " + formatDuration(p.TimePlayed)),
+				),
+				app.Li().Text("Last played: " + time.Unix(Stats.LastPlayed, 0).Format("2006-01-02")),
+				app.If(p.Rating.Games > 0,
+					app.Li().Text(fmt.Sprintf("Elo rating: %.0f", p.Rating.Rating)),
+				),
+			),
+			app.H3().Text("Favourite games"),
+			app.Ol().Body(
+				app.Range(Stats.Favourites).Slice(func(i int) app.UI {
+					if i >= 5 {
+						return app.Text("")
+					}
+					Favourite := Stats.Favourites[i]
+					return app.Li().Text(fmt.Sprintf("%v (%v plays)", p.Boards[Favourite.Board].Text, Favourite.Plays))
+				}),
+			),
+			app.If(len(p.History) > 0,
+				app.H3().Text("Rating history"),
+				app.Raw(lineChart(p.historySeries(), dateTick)),
+				app.Table().Body(
+					app.Range(p.History).Slice(func(i int) app.UI {
+						Point := p.History[len(p.History) - i - 1]
+						return app.Tr().Body(
+							app.Td().Text(time.Unix(Point.Date, 0).Format("2006-01-02")),
+							app.Td().Text(fmt.Sprintf("%.0f", Point.Rating)),
+						)
+					}),
+				),
+			),
+		),
+		app.Button().Text("head to head").OnClick(p.onHeadToHead),
+		app.Button().Text("close").OnClick(p.onClose),
+	)
+}
+
+func (p *playerpage) historySeries() []chartSeries {
+	Line := chartSeries{Name: p.Player.Text}
+	for _, Point := range p.History {
+		Line.Points = append(Line.Points, chartPoint{X: float64(Point.Date), Y: Point.Rating})
+	}
+	return []chartSeries{Line}
+}
+
+func (p *playerpage) onHeadToHead(ctx app.Context, e app.Event) {
+	p.Full.Rival = -1
+	p.Full.Section = SHeadToHead
+	p.Full.Update()
+}
+
+func (p *playerpage) onClose(ctx app.Context, e app.Event) {
+	p.Full.Section = SPlayers
+	p.Full.Update()
+}
+
 type boardspage struct {
 	app.Compo
 
 	Full *fullpage
+	Store Store
 	Boards []board
+	// games rolled up to their board, by board ID
+	Plays map[int]stats.BoardPlays
+	// games broken down by the expansions played, most played first
+	Combos []stats.BoardPlays
+	ByCombo bool
+
+	// position in Boards of the board being renamed or merged
+	Selected int
+	Renaming bool
+	Input string
+	Merging bool
+	MergeInto int
+	Affected int
+	EditingSheet bool
+	EditingFactions bool
 }
 
 func (b *boardspage) OnMount(ctx app.Context) {
+	b.load()
+}
+
+func (b *boardspage) load() {
 	var err error
-	b.Boards, err = retrieveAllBoards()
+	b.Boards, err = retrieveAllBoards(b.Store)
 	if err != nil {
 		app.Log("%s", errors.New("error retrieving boards").Wrap(err))
 		return
 	}
+	Games, err := retrieveAllGames(b.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error retrieving games").Wrap(err))
+		return
+	}
+	b.Plays = stats.PlaysByBoard(Games)
+	b.Combos = make([]stats.BoardPlays, 0)
+	for _, Plays := range stats.PlaysByCombo(Games) {
+		b.Combos = append(b.Combos, Plays)
+	}
+	sort.SliceStable(b.Combos, func(i, j int) bool {
+		if b.Combos[i].Plays != b.Combos[j].Plays {
+			return b.Combos[i].Plays > b.Combos[j].Plays
+		}
+		return stats.ComboKey(b.Combos[i].Combo) < stats.ComboKey(b.Combos[j].Combo)
+	})
+	b.Renaming = false
+	b.Merging = false
+	b.EditingSheet = false
+	b.EditingFactions = false
 	b.Update()
 }
 
 func  (b *boardspage) Render() app.UI {
 	return app.Div().Body(
 		app.H2().Text("Games"),
+		app.Div().Body(
+			app.Input().Type("checkbox").Checked(b.ByCombo).OnChange(b.onByCombo),
+			app.Text("Break plays down by expansions and variants"),
+		),
 		app.Ul().Body(
 			app.Range(b.Boards).Slice(func(i int) app.UI {
 				Board := b.Boards[i]
@@ -631,13 +2535,112 @@ func  (b *boardspage) Render() app.UI {
 				if Board.Hidden {
 					show = "show"
 				}
+				Plays := b.Plays[Board.ID]
+				addon := "expansion"
+				if Board.Variant {
+					addon = "variant"
+				}
 				return app.Li().Body(
-					app.Text(Board.Text),
+					app.If(b.Renaming && b.Selected == i,
+						app.Input().Value(b.Input).OnInput(b.onRenameInput),
+						app.Button().Text("save").
+							Disabled(len(strings.TrimSpace(b.Input)) == 0).
+							OnClick(b.onRename),
+						app.Button().Text("cancel").OnClick(b.onCancel),
+					).Else(
+						app.Button().Text(Board.Text).
+							DataSet("board", Board.ID).
+							OnClick(b.onBoard),
+					),
+					app.If(Board.HasBase,
+						app.Text(" (" + addon + " of " + findBoard(b.Boards, Board.Base).Text + ")"),
+					).Else(
+						app.Text(" " + playsText(Plays)),
+					),
+					app.If(b.ByCombo && !Board.HasBase,
+						app.Ul().Body(
+							app.Range(b.Combos).Slice(func(c int) app.UI {
+								Combo := b.Combos[c]
+								if Combo.Combo[0] != Board.ID {
+									return app.Text("")
+								}
+								return app.Li().Text(comboName(Combo.Combo, b.Boards) + " " + playsText(Combo))
+							}),
+						),
+					),
+					scoringSelect(Board.Scoring, b.onScoring).DataSet("board", i),
+					app.Text(" played with "),
+					app.Select().DataSet("board", i).OnChange(b.onBase).Body(
+						app.Option().Value(-1).Text("nothing else (base game)").Selected(!Board.HasBase),
+						app.Range(b.Boards).Slice(func(j int) app.UI {
+							Base := b.Boards[j]
+							if Base.ID == Board.ID || Base.HasBase {
+								return app.Text("")
+							}
+							return app.Option().Value(Base.ID).Text(Base.Text).
+								Selected(Board.HasBase && Board.Base == Base.ID)
+						}),
+					),
+					app.If(Board.HasBase,
+						app.Input().Type("checkbox").DataSet("board", i).Checked(Board.Variant).OnChange(b.onVariant),
+						app.Text("variant"),
+					),
+					app.If(b.EditingSheet && b.Selected == i,
+						app.Div().Body(
+							app.Text("Score sheet categories, separated by commas: "),
+							app.Input().Value(b.Input).OnInput(b.onRenameInput),
+							app.Button().Text("save").OnClick(b.onSheet),
+							app.Button().Text("cancel").OnClick(b.onCancel),
+						),
+					).ElseIf(len(Board.Categories) > 0,
+						app.Text(" (" + strings.Join(Board.Categories, ", ") + ")"),
+					),
+					app.If(b.EditingFactions && b.Selected == i,
+						app.Div().Body(
+							app.Text("Factions, roles or colors, separated by commas: "),
+							app.Input().Value(b.Input).OnInput(b.onRenameInput),
+							app.Button().Text("save").OnClick(b.onFactions),
+							app.Button().Text("cancel").OnClick(b.onCancel),
+						),
+					),
 					app.Button().Text(show).
 						DataSet("board", i).
 						OnClick(b.onToggle),
+					app.Button().Text("rename").
+						DataSet("board", i).
+						OnClick(b.onStartRename),
+					app.Button().Text("score sheet").
+						DataSet("board", i).
+						OnClick(b.onStartSheet),
+					app.Button().Text("factions").
+						DataSet("board", i).
+						OnClick(b.onStartFactions),
+					app.Button().Text("merge").
+						DataSet("board", i).
+						OnClick(b.onStartMerge),
 				)
 			})),
+		app.If(b.Merging,
+			app.Div().Body(
+				app.Text(fmt.Sprintf("Merge %v into ", b.Boards[b.Selected].Text)),
+				app.Select().OnChange(b.onMergeInto).Body(
+					app.Option().Value(-1).Text("choose a game").Selected(b.MergeInto < 0),
+					app.Range(b.Boards).Slice(func(i int) app.UI {
+						if i == b.Selected {
+							return app.Text("")
+						}
+						return app.Option().Value(i).Text(b.Boards[i].Text).Selected(b.MergeInto == i)
+					}),
+				),
+				app.If(b.MergeInto >= 0,
+					app.P().Text(fmt.Sprintf("%v recorded games will be changed.", b.Affected)),
+				),
+				app.Button().Text("merge").
+					Disabled(b.MergeInto < 0).
+					OnClick(b.onMerge),
+				app.Button().Text("cancel").OnClick(b.onCancel),
+			),
+		),
 		app.Button().Text("close").OnClick(b.onClose),
 	)
 }
@@ -649,12 +2652,847 @@ func (b *boardspage) onToggle(ctx app.Context, e app.Event) {
 		app.Log("%s", "Unknown board for onToggle")
 	}
 	b.Boards[i].Hidden = !b.Boards[i].Hidden
-	b.Boards[i].store()
+	storeBoard(b.Store, b.Boards[i])
 	b.Update()
 }
 
-func (b *boardspage) onClose(ctx app.Context, e app.Event) {
-	b.Full.Section = SMenu
-	b.Full.Update()	
+// playsText tells how often a board was played and for how long on average.
+func playsText(Plays stats.BoardPlays) string {
+	if Plays.Average > 0 {
+		return fmt.Sprintf("(%v plays, %v on average)", Plays.Plays, formatDuration(Plays.Average))
+	}
+	return fmt.Sprintf("(%v plays)", Plays.Plays)
+}
+
+func (b *boardspage) onBoard(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onBoard")
+		return
+	}
+	b.Full.Board = id
+	b.Full.Section = SBoard
+	b.Full.Update()
+}
+
+func (b *boardspage) onByCombo(ctx app.Context, e app.Event) {
+	b.ByCombo = ctx.JSSrc.Get("checked").Bool()
+	b.Update()
+}
+
+// onBase makes a board an expansion of another one, or a base game again.
+// Boards that have expansions cannot become one.
+func (b *boardspage) onBase(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onBase")
+		return
+	}
+	Base, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown base board for onBase")
+		return
+	}
+	if Base >= 0 && len(addonsOf(b.Boards, b.Boards[i].ID)) > 0 {
+		app.Log("%s", errors.Newf("board %v has expansions of its own", b.Boards[i].ID))
+		ctx.JSSrc.Set("value", "-1")
+		return
+	}
+	b.Boards[i].HasBase = Base >= 0
+	b.Boards[i].Base = Base
+	if Base < 0 {
+		b.Boards[i].Base = 0
+		b.Boards[i].Variant = false
+	}
+	if err := storeBoard(b.Store, b.Boards[i]); err != nil {
+		app.Log("%s", errors.New("error storing board").Wrap(err))
+	}
+	b.Update()
+}
+
+func (b *boardspage) onVariant(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onVariant")
+		return
+	}
+	b.Boards[i].Variant = ctx.JSSrc.Get("checked").Bool()
+	if err := storeBoard(b.Store, b.Boards[i]); err != nil {
+		app.Log("%s", errors.New("error storing board").Wrap(err))
+	}
+	b.Update()
+}
+
+func (b *boardspage) onScoring(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onScoring")
+		return
+	}
+	m, err := scoringValue(ctx)
+	if err != nil {
+		app.Log("%s", err)
+		return
+	}
+	b.Boards[i].Scoring = m
+	if err := storeBoard(b.Store, b.Boards[i]); err != nil {
+		app.Log("%s", errors.New("error storing board").Wrap(err))
+	}
+	b.Update()
+}
+
+func (b *boardspage) onStartRename(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onStartRename")
+		return
+	}
+	b.Selected = i
+	b.Input = b.Boards[i].Text
+	b.Renaming = true
+	b.Merging = false
+	b.EditingSheet = false
+	b.EditingFactions = false
+	b.Update()
+}
+
+func (b *boardspage) onRenameInput(ctx app.Context, e app.Event) {
+	b.Input = ctx.JSSrc.Get("value").String()
+	b.Update()
+}
+
+func (b *boardspage) onRename(ctx app.Context, e app.Event) {
+	Board := b.Boards[b.Selected]
+	Board.Text = strings.TrimSpace(b.Input)
+	if err := storeBoard(b.Store, Board); err != nil {
+		app.Log("%s", errors.New("error renaming board").Wrap(err))
+		return
+	}
+	b.Boards[b.Selected] = Board
+	b.Renaming = false
+	b.Update()
+}
+
+func (b *boardspage) onStartMerge(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onStartMerge")
+		return
+	}
+	b.Selected = i
+	b.MergeInto = -1
+	b.Merging = true
+	b.Renaming = false
+	b.EditingSheet = false
+	b.EditingFactions = false
+	b.Update()
 }
 
+func (b *boardspage) onStartSheet(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onStartSheet")
+		return
+	}
+	b.Selected = i
+	b.Input = strings.Join(b.Boards[i].Categories, ", ")
+	b.EditingSheet = true
+	b.EditingFactions = false
+	b.Renaming = false
+	b.Merging = false
+	b.Update()
+}
+
+func (b *boardspage) onStartFactions(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("board").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onStartFactions")
+		return
+	}
+	b.Selected = i
+	b.Input = strings.Join(b.Boards[i].Factions, ", ")
+	b.EditingFactions = true
+	b.EditingSheet = false
+	b.Renaming = false
+	b.Merging = false
+	b.Update()
+}
+
+// onFactions stores the factions of the board. Games keep the factions they
+// were recorded with, even the ones taken off the list.
+func (b *boardspage) onFactions(ctx app.Context, e app.Event) {
+	Board := b.Boards[b.Selected]
+	Board.Factions = parseNames(b.Input)
+	if len(Board.Factions) == 0 {
+		Board.Factions = nil
+	}
+	if err := storeBoard(b.Store, Board); err != nil {
+		app.Log("%s", errors.New("error storing board").Wrap(err))
+		return
+	}
+	b.Boards[b.Selected] = Board
+	b.EditingFactions = false
+	b.Update()
+}
+
+// onSheet stores the categories of the board. Games already recorded keep
+// the sheet they were recorded with.
+func (b *boardspage) onSheet(ctx app.Context, e app.Event) {
+	Board := b.Boards[b.Selected]
+	Board.Categories = parseNames(b.Input)
+	if len(Board.Categories) == 0 {
+		Board.Categories = nil
+	}
+	if err := storeBoard(b.Store, Board); err != nil {
+		app.Log("%s", errors.New("error storing board").Wrap(err))
+		return
+	}
+	b.Boards[b.Selected] = Board
+	b.EditingSheet = false
+	b.Update()
+}
+
+func (b *boardspage) onMergeInto(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onMergeInto")
+		return
+	}
+	b.MergeInto = i
+	if i >= 0 {
+		affected, _, err := mergePreview(b.Store, "board", b.Boards[i].ID, b.Boards[b.Selected].ID)
+		if err != nil {
+			app.Log("%s", errors.New("error previewing merge").Wrap(err))
+			return
+		}
+		b.Affected = len(affected)
+	}
+	b.Update()
+}
+
+func (b *boardspage) onMerge(ctx app.Context, e app.Event) {
+	if err := mergeBoards(b.Store, b.Boards[b.MergeInto].ID, b.Boards[b.Selected].ID); err != nil {
+		app.Log("%s", errors.New("error merging boards").Wrap(err))
+		return
+	}
+	b.load()
+}
+
+func (b *boardspage) onCancel(ctx app.Context, e app.Event) {
+	b.Renaming = false
+	b.Merging = false
+	b.EditingSheet = false
+	b.EditingFactions = false
+	b.Update()
+}
+
+func (b *boardspage) onClose(ctx app.Context, e app.Event) {
+	b.Full.Section = SMenu
+	b.Full.Update()	
+}
+
+type locationspage struct {
+	app.Compo
+
+	Full *fullpage
+	Store Store
+	Locations []location
+
+	// position in Locations of the location being renamed
+	Selected int
+	Renaming bool
+	Input string
+}
+
+func (l *locationspage) OnMount(ctx app.Context) {
+	var err error
+	l.Locations, err = retrieveAllLocations(l.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error retrieving locations").Wrap(err))
+		return
+	}
+	l.Update()
+}
+
+func  (l *locationspage) Render() app.UI {
+	return app.Div().Body(
+		app.H2().Text("Locations"),
+		app.If(len(l.Locations) == 0,
+			app.P().Text("Locations are added from a session."),
+		),
+		app.Ul().Body(
+			app.Range(l.Locations).Slice(func(i int) app.UI {
+				Location := l.Locations[i]
+				show := "hide"
+				if Location.Hidden {
+					show = "show"
+				}
+				return app.Li().Body(
+					app.If(l.Renaming && l.Selected == i,
+						app.Input().Value(l.Input).OnInput(l.onRenameInput),
+						app.Button().Text("save").
+							Disabled(len(strings.TrimSpace(l.Input)) == 0).
+							OnClick(l.onRename),
+						app.Button().Text("cancel").OnClick(l.onCancel),
+					).Else(
+						app.Text(Location.Text),
+					),
+					app.Button().Text(show).
+						DataSet("location", i).
+						OnClick(l.onToggle),
+					app.Button().Text("rename").
+						DataSet("location", i).
+						OnClick(l.onStartRename),
+				)
+			})),
+		app.Button().Text("close").OnClick(l.onClose),
+	)
+}
+
+func (l *locationspage) onToggle(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("location").String())
+	if err != nil {
+		app.Log("%s", "Unknown location for onToggle")
+		return
+	}
+	l.Locations[i].Hidden = !l.Locations[i].Hidden
+	if err := storeLocation(l.Store, l.Locations[i]); err != nil {
+		app.Log("%s", errors.New("error storing location").Wrap(err))
+	}
+	l.Update()
+}
+
+func (l *locationspage) onStartRename(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("location").String())
+	if err != nil {
+		app.Log("%s", "Unknown location for onStartRename")
+		return
+	}
+	l.Selected = i
+	l.Input = l.Locations[i].Text
+	l.Renaming = true
+	l.Update()
+}
+
+func (l *locationspage) onRenameInput(ctx app.Context, e app.Event) {
+	l.Input = ctx.JSSrc.Get("value").String()
+	l.Update()
+}
+
+func (l *locationspage) onRename(ctx app.Context, e app.Event) {
+	Location := l.Locations[l.Selected]
+	Location.Text = strings.TrimSpace(l.Input)
+	if err := storeLocation(l.Store, Location); err != nil {
+		app.Log("%s", errors.New("error renaming location").Wrap(err))
+		return
+	}
+	l.Locations[l.Selected] = Location
+	l.Renaming = false
+	l.Update()
+}
+
+func (l *locationspage) onCancel(ctx app.Context, e app.Event) {
+	l.Renaming = false
+	l.Update()
+}
+
+func (l *locationspage) onClose(ctx app.Context, e app.Event) {
+	l.Full.Section = SMenu
+	l.Full.Update()
+}
+
+type boardpage struct {
+	app.Compo
+
+	Full *fullpage
+	Store Store
+	BoardID int
+	Board board
+	Stats stats.BoardStats
+	Factions []stats.FactionRecord
+	Seats []stats.SeatRecord
+	Histogram []histogramBin
+	// most recent first
+	Games []game
+	Dates map[int]int64
+	Results map[int]string
+	Players []player
+	Boards []board
+}
+
+func (b *boardpage) OnMount(ctx app.Context) {
+	Log, err := loadBoardLogbook(b.Store, b.BoardID)
+	if err != nil {
+		app.Log("%s", errors.New("error loading games of board").Wrap(err))
+		return
+	}
+	b.Board = Log.Boards[b.BoardID]
+	b.Stats = stats.ComputeBoardStats(Log, b.Board)
+	b.Factions = stats.FactionStats(b.Board, Log.Games, Log.Scores)
+	b.Seats = stats.SeatStats(b.Board, Log.Games, Log.Scores)
+	b.Histogram = scoreHistogram(Log, b.Board, 12)
+	b.Players = Log.Players
+	b.Boards = make([]board, 0, len(Log.Boards))
+	for _, Board := range Log.Boards {
+		b.Boards = append(b.Boards, Board)
+	}
+	b.Games = make([]game, len(Log.Games))
+	b.Dates = map[int]int64{}
+	b.Results = map[int]string{}
+	for idx, Game := range Log.Games {
+		b.Games[len(Log.Games) - idx - 1] = Game
+		b.Dates[Game.ID] = Log.Sessions[Game.Session].Date
+		b.Results[Game.ID] = resultText(b.Board.Scoring, Game, Log.Scores[Game.ID], Log.Players)
+	}
+	b.Update()
+}
+
+// playerNames joins the names of the players, as in "Alice, Bob".
+func playerNames(IDs []int, Players []player) string {
+	names := make([]string, len(IDs))
+	for idx, ID := range IDs {
+		names[idx] = findPlayer(Players, ID).Text
+	}
+	return strings.Join(names, ", ")
+}
+
+func  (b *boardpage) Render() app.UI {
+	Stats := b.Stats
+	return app.Div().Body(
+		app.H2().Text(b.Board.Text),
+		app.If(Stats.Plays == 0,
+			app.P().Text("Not played yet."),
+		).Else(
+			app.Ul().Body(
+				app.Li().Text(fmt.Sprintf("Played %v times, last on %v", Stats.Plays,
+					time.Unix(Stats.LastPlayed, 0).Format("2006-01-02"))),
+				app.If(Stats.HasScores,
+					app.Li().Text(fmt.Sprintf("Scores: high %v, low %v, average %.1f",
+						formatScore(Stats.HighScore), formatScore(Stats.LowScore), Stats.AverageScore)),
+					app.Li().Text(fmt.Sprintf("Record: %v by %v",
+						formatScore(Stats.Record), playerNames(Stats.RecordHolders, b.Players))),
+				),
+				app.If(Stats.TopWins > 0,
+					app.Li().Text(fmt.Sprintf("Wins most: %v (%v wins)",
+						playerNames(Stats.TopWinners, b.Players), Stats.TopWins)),
+				),
+			),
+			app.If(len(b.Histogram) > 0,
+				app.H3().Text("Scores"),
+				app.Raw(barChart(histogramBars(b.Histogram))),
+			),
+			app.If(len(b.Factions) > 0,
+				app.H3().Text("Factions"),
+				app.Ul().Body(
+					app.Range(b.Factions).Slice(func(i int) app.UI {
+						Record := b.Factions[i]
+						if Record.Plays == 0 {
+							return app.Text("")
+						}
+						return app.Li().Text(fmt.Sprintf("%v: %v wins in %v plays (%.0f%%)",
+							Record.Faction, Record.Wins, Record.Plays, stats.WinRate(Record.Wins, Record.Plays)))
+					}),
+				),
+			),
+			app.If(len(b.Seats) > 0,
+				app.H3().Text("Turn order"),
+				app.Ul().Body(
+					app.Range(b.Seats).Slice(func(i int) app.UI {
+						Record := b.Seats[i]
+						return app.Li().Text(fmt.Sprintf("Seat %v: %v wins in %v plays (%.0f%%)",
+							Record.Seat, Record.Wins, Record.Plays, stats.WinRate(Record.Wins, Record.Plays)))
+					}),
+				),
+			),
+			app.H3().Text("Games"),
+			app.Ul().Body(
+				app.Range(b.Games).Slice(func(i int) app.UI {
+					Game := b.Games[i]
+					return app.Li().Body(
+						app.Button().
+							Text(time.Unix(b.Dates[Game.ID], 0).Format("2006-01-02") + " " + comboName(stats.ComboOf(Game), b.Boards)).
+							DataSet("session", Game.Session).
+							DataSet("game", Game.ID).
+							OnClick(b.onGame),
+						app.Text(" " + b.Results[Game.ID]),
+					)
+				}),
+			),
+		),
+		app.Button().Text("close").OnClick(b.onClose),
+	)
+}
+
+func (b *boardpage) onGame(ctx app.Context, e app.Event) {
+	Session, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("session").String())
+	if err != nil {
+		app.Log("%s", "Unknown session for onGame")
+		return
+	}
+	Game, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("game").String())
+	if err != nil {
+		app.Log("%s", "Unknown game for onGame")
+		return
+	}
+	b.Full.Session = Session
+	b.Full.Game = Game
+	b.Full.Section = SGame
+	b.Full.Previous = SBoard
+	b.Full.Update()
+}
+
+func (b *boardpage) onClose(ctx app.Context, e app.Event) {
+	b.Full.Section = SGames
+	b.Full.Update()
+}
+
+type ratingspage struct {
+	app.Compo
+
+	Full *fullpage
+	Store Store
+	Log stats.Logbook
+	Boards []board
+	System ratingSystem
+	// -1 for every board
+	Board int
+	Leaderboard []rating
+}
+
+func (r *ratingspage) OnMount(ctx app.Context) {
+	var err error
+	if r.Log, err = loadLogbook(r.Store); err != nil {
+		app.Log("%s", errors.New("error loading logbook").Wrap(err))
+		return
+	}
+	if r.Boards, err = retrieveAllBoards(r.Store); err != nil {
+		app.Log("%s", errors.New("error retrieving boards").Wrap(err))
+		return
+	}
+	r.Board = -1
+	r.rate()
+}
+
+func (r *ratingspage) rate() {
+	r.Leaderboard = computeRatings(r.Log, r.System, r.Board).leaderboard()
+	r.Update()
+}
+
+func  (r *ratingspage) Render() app.UI {
+	return app.Div().Body(
+		app.H2().Text("Ratings"),
+		app.Div().Body(
+			app.Select().OnChange(r.onSystem).Body(
+				app.Range(ratingSystems).Slice(func(i int) app.UI {
+					return app.Option().Value(int(ratingSystems[i])).
+						Text(ratingSystems[i].String()).
+						Selected(ratingSystems[i] == r.System)
+				}),
+			),
+			app.Text(" for "),
+			app.Select().OnChange(r.onBoard).Body(
+				app.Option().Value(-1).Text("all games").Selected(r.Board < 0),
+				app.Range(r.Boards).Slice(func(i int) app.UI {
+					Board := r.Boards[i]
+					if Board.HasBase {
+						return app.Text("")
+					}
+					return app.Option().Value(Board.ID).Text(Board.Text).Selected(r.Board == Board.ID)
+				}),
+			),
+		),
+		app.If(len(r.Leaderboard) == 0,
+			app.P().Text("No competitive games recorded yet."),
+		).Else(
+			app.Table().Body(
+				app.Tr().Body(
+					app.Th(),
+					app.Th().Text("Player"),
+					app.Th().Text("Rating"),
+					app.Th().Text("Games"),
+				),
+				app.Range(r.Leaderboard).Slice(func(i int) app.UI {
+					Rating := r.Leaderboard[i]
+					value := fmt.Sprintf("%.0f", Rating.Rating)
+					if r.System == Glicko2 {
+						value += fmt.Sprintf(" ± %.0f", 2 * Rating.Deviation)
+					}
+					return app.Tr().Body(
+						app.Td().Text(fmt.Sprintf("%v.", i + 1)),
+						app.Td().Body(
+							app.Button().Text(findPlayer(r.Log.Players, Rating.Player).Text).
+								DataSet("player", Rating.Player).
+								OnClick(r.onPlayer),
+						),
+						app.Td().Text(value),
+						app.Td().Text(fmt.Sprintf("%v", Rating.Games)),
+					)
+				}),
+			),
+		),
+		app.Button().Text("close").OnClick(r.onClose),
+	)
+}
+
+func (r *ratingspage) onSystem(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil || i < 0 || i >= len(ratingSystems) {
+		app.Log("%s", "Unknown rating system for onSystem")
+		return
+	}
+	r.System = ratingSystem(i)
+	r.rate()
+}
+
+func (r *ratingspage) onBoard(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown board for onBoard")
+		return
+	}
+	r.Board = i
+	r.rate()
+}
+
+func (r *ratingspage) onPlayer(ctx app.Context, e app.Event) {
+	id, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("player").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onPlayer")
+		return
+	}
+	r.Full.Player = id
+	r.Full.Section = SPlayer
+	r.Full.Update()
+}
+
+func (r *ratingspage) onClose(ctx app.Context, e app.Event) {
+	r.Full.Section = SMenu
+	r.Full.Update()
+}
+
+type headtoheadpage struct {
+	app.Compo
+
+	Full *fullpage
+	Store Store
+	// the compared players, -1 until picked
+	A int
+	B int
+	Log stats.Logbook
+	Boards []board
+	H stats.HeadToHead
+}
+
+func (h *headtoheadpage) OnMount(ctx app.Context) {
+	var err error
+	if h.Log, err = loadLogbook(h.Store); err != nil {
+		app.Log("%s", errors.New("error loading logbook").Wrap(err))
+		return
+	}
+	h.Boards = make([]board, 0, len(h.Log.Boards))
+	for _, Board := range h.Log.Boards {
+		h.Boards = append(h.Boards, Board)
+	}
+	h.compare()
+}
+
+func (h *headtoheadpage) compare() {
+	h.H = stats.ComputeHeadToHead(h.Log, h.A, h.B)
+	h.Update()
+}
+
+func (h *headtoheadpage) playerSelect(Selected int, onChange app.EventHandler) app.UI {
+	return app.Select().OnChange(onChange).Body(
+		app.Option().Value(-1).Text("Select player").Selected(Selected < 0),
+		app.Range(h.Log.Players).Slice(func(i int) app.UI {
+			Player := h.Log.Players[i]
+			if Player.Hidden && Player.ID != Selected {
+				return app.Text("")
+			}
+			return app.Option().Value(Player.ID).Text(Player.Text).Selected(Player.ID == Selected)
+		}),
+	)
+}
+
+func  (h *headtoheadpage) Render() app.UI {
+	H := h.H
+	NameA := findPlayer(h.Log.Players, h.A).Text
+	NameB := findPlayer(h.Log.Players, h.B).Text
+	return app.Div().Body(
+		app.H2().Text("Head to head"),
+		app.Div().Body(
+			h.playerSelect(h.A, h.onPlayerA),
+			app.Text(" vs "),
+			h.playerSelect(h.B, h.onPlayerB),
+		),
+		app.If(h.A < 0 || h.B < 0 || h.A == h.B,
+			app.P().Text("Pick two players to compare."),
+		).ElseIf(len(H.Games) == 0,
+			app.P().Text("They have not played together yet."),
+		).Else(
+			app.Ul().Body(
+				app.Li().Text(fmt.Sprintf("Games together: %v", len(H.Games))),
+				app.Li().Text(fmt.Sprintf("%v ahead: %v", NameA, H.AheadA)),
+				app.Li().Text(fmt.Sprintf("%v ahead: %v", NameB, H.AheadB)),
+				app.If(H.Ties > 0,
+					app.Li().Text(fmt.Sprintf("Ties: %v", H.Ties)),
+				),
+				app.If(H.Together > 0,
+					app.Li().Text(fmt.Sprintf("On the same side: %v", H.Together)),
+				),
+				app.If(H.Streak > 0,
+					app.Li().Text(fmt.Sprintf("Longest streak: %v, %v in a row",
+						findPlayer(h.Log.Players, H.StreakPlayer).Text, H.Streak)),
+				),
+			),
+			app.H3().Text("By game"),
+			app.Table().Body(
+				app.Tr().Body(
+					app.Th().Text("Game"),
+					app.Th().Text("Played"),
+					app.Th().Text(NameA),
+					app.Th().Text(NameB),
+					app.Th().Text("Ties"),
+				),
+				app.Range(H.Boards).Slice(func(i int) app.UI {
+					Record := H.Boards[i]
+					return app.Tr().Body(
+						app.Td().Text(h.Log.Boards[Record.Board].Text),
+						app.Td().Text(fmt.Sprintf("%v", Record.Games)),
+						app.Td().Text(fmt.Sprintf("%v", Record.AheadA)),
+						app.Td().Text(fmt.Sprintf("%v", Record.AheadB)),
+						app.Td().Text(fmt.Sprintf("%v", Record.Ties)),
+					)
+				}),
+			),
+			app.H3().Text("Games"),
+			app.Ul().Body(
+				app.Range(H.Games).Slice(func(i int) app.UI {
+					Game := h.game(H.Games[len(H.Games) - i - 1])
+					Board := h.Log.Boards[Game.Board]
+					return app.Li().Body(
+						app.Button().
+							Text(time.Unix(h.Log.Sessions[Game.Session].Date, 0).Format("2006-01-02") + " " + comboName(stats.ComboOf(Game), h.Boards)).
+							DataSet("session", Game.Session).
+							DataSet("game", Game.ID).
+							OnClick(h.onGame),
+						app.Text(" " + resultText(Board.Scoring, Game, h.Log.Scores[Game.ID], h.Log.Players)),
+					)
+				}),
+			),
+		),
+		app.Button().Text("close").OnClick(h.onClose),
+	)
+}
+
+// game finds a game of the logbook by ID.
+func (h *headtoheadpage) game(ID int) game {
+	for _, Game := range h.Log.Games {
+		if Game.ID == ID {
+			return Game
+		}
+	}
+	return game{}
+}
+
+func (h *headtoheadpage) onPlayerA(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onPlayerA")
+		return
+	}
+	h.A = i
+	h.Full.Player = i
+	h.compare()
+}
+
+func (h *headtoheadpage) onPlayerB(ctx app.Context, e app.Event) {
+	i, err := strconv.Atoi(ctx.JSSrc.Get("value").String())
+	if err != nil {
+		app.Log("%s", "Unknown player for onPlayerB")
+		return
+	}
+	h.B = i
+	h.Full.Rival = i
+	h.compare()
+}
+
+func (h *headtoheadpage) onGame(ctx app.Context, e app.Event) {
+	Session, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("session").String())
+	if err != nil {
+		app.Log("%s", "Unknown session for onGame")
+		return
+	}
+	Game, err := strconv.Atoi(ctx.JSSrc.Get("dataset").Get("game").String())
+	if err != nil {
+		app.Log("%s", "Unknown game for onGame")
+		return
+	}
+	h.Full.Session = Session
+	h.Full.Game = Game
+	h.Full.Section = SGame
+	h.Full.Previous = SHeadToHead
+	h.Full.Update()
+}
+
+func (h *headtoheadpage) onClose(ctx app.Context, e app.Event) {
+	h.Full.Section = SMenu
+	h.Full.Update()
+}
+
+type chartspage struct {
+	app.Compo
+
+	Full *fullpage
+	Store Store
+	Sessions string
+	Plays string
+	Ratings string
+	HasGames bool
+	HasRatings bool
+}
+
+func (c *chartspage) OnMount(ctx app.Context) {
+	Log, err := loadLogbook(c.Store)
+	if err != nil {
+		app.Log("%s", errors.New("error loading logbook").Wrap(err))
+		return
+	}
+	c.Sessions = barChart(monthBars(sessionsPerMonth(Log)))
+	c.HasGames = len(Log.Games) > 0
+	c.Plays = lineChart(boardLines(playsPerMonth(Log, 5), Log.Boards), monthTick)
+	Ratings := computeRatings(Log, Elo, -1)
+	Players := make([]int, 0, len(chartColors))
+	for _, Rating := range Ratings.leaderboard() {
+		if len(Players) < len(chartColors) {
+			Players = append(Players, Rating.Player)
+		}
+	}
+	c.HasRatings = len(Players) > 0
+	c.Ratings = lineChart(ratingSeries(Ratings, Players, Log.Players), dateTick)
+	c.Update()
+}
+
+func  (c *chartspage) Render() app.UI {
+	return app.Div().Body(
+		app.H2().Text("Charts"),
+		app.If(!c.HasGames,
+			app.P().Text("No games recorded yet."),
+		).Else(
+			app.H3().Text("Sessions per month"),
+			app.Raw(c.Sessions),
+			app.H3().Text("Plays per month"),
+			app.Raw(c.Plays),
+			app.If(c.HasRatings,
+				app.H3().Text("Elo ratings"),
+				app.Raw(c.Ratings),
+			),
+		),
+		app.Button().Text("close").OnClick(c.onClose),
+	)
+}
+
+func (c *chartspage) onClose(ctx app.Context, e app.Event) {
+	c.Full.Section = SMenu
+	c.Full.Update()
+}
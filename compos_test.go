@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseScore(t *testing.T) {
+	tests := []struct {
+		value string
+		score float32
+		ok bool
+	}{
+		{"", 0, true},
+		{" 12 ", 12, true},
+		{"-3.5", -3.5, true},
+		{"1e3", 1000, true},
+		{"abc", 0, false},
+		{"NaN", 0, false},
+		{"nan", 0, false},
+		{"Inf", 0, false},
+		{"-Infinity", 0, false},
+		{"1e999", 0, false},
+		// past the float32 range
+		{"1e39", 0, false},
+	}
+	for _, test := range tests {
+		if score, ok := parseScore(test.value); score != test.score || ok != test.ok {
+			t.Errorf("parseScore(%q) = %v, %v; want %v, %v", test.value, score, ok, test.score, test.ok)
+		}
+	}
+}
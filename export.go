@@ -0,0 +1,127 @@
+package main
+
+import (
+	"time"
+
+	"github.com/maxence-charriere/go-app/v7/pkg/errors"
+
+	"github.com/Textualization/boardgame-logbook/stats"
+)
+
+// exportVersion is the format of the documents produced by exportLogbook,
+// stored in their Version field. Bump it whenever the meaning of existing
+// fields changes so imports can tell which format they are reading. Fields
+// that are only added do not bump it: older documents read them as zero
+// values.
+//
+// Version 2:
+//
+//	{
+//	  "Version": 2,
+//	  "Exported": <unix time>,
+//	  "Sessions": [ { "ID", "Date", "Notes", "HasLocation", "Location", "Attendees": [ <player ID> ],
+//	                  "Games": [ { "ID", "Board", "Session", "Players": [ <player ID> ], "SeatOrder",
+//	                               "PositionalScores", "Won", "Teams": [ { "Name", "Players": [ <player ID> ], "Score" } ],
+//	                               "Categories": [ name ], "Breakdown": { "<player ID>": { "<category>": score } },
+//	                               "Start": <unix time>, "End": <unix time>, "Notes", "Expansions": [ <board ID> ],
+//	                               "Factions": { "<player ID>": faction },
+//	                               "Scores": { "<player ID>": score } } ] } ],
+//	  "Players": [ { "ID", "Text", "Hidden" } ],
+//	  "Boards": [ { "ID", "Text", "Hidden", "Scoring", "Categories": [ name ], "HasBase", "Base", "Variant",
+//	                "Factions": [ name ] } ],
+//	  "Locations": [ { "ID", "Text", "Hidden" } ],
+//	  "HeadToHead": [ { "PlayerA", "PlayerB", "Games": [ <game ID> ], "AheadA", "AheadB", "Ties", "Together",
+//	                    "Boards": [ { "Board", "Games", "AheadA", "AheadB", "Ties" } ], "StreakPlayer", "Streak" } ]
+//	}
+//
+// Scoring is 0 for highest score wins, 1 for lowest score wins, 2 for
+// cooperative (Won tells the outcome) and 3 for finishing order (the score is
+// the place, 1 for first).
+//
+// Start and End are 0 when the time of the game is not known. Boards with
+// HasBase are expansions or variants of the board Base; games are recorded
+// under the base board and list the ones played with it in Expansions. With
+// SeatOrder, the Players of a game are in turn order, first player first.
+//
+// HeadToHead is computed from the games, as on the head to head page, for
+// every two players who played together. Imports ignore it.
+//
+// Version 1 had no Players nor PositionalScores in games, and its scores were
+// keyed by position in the player list rather than by player ID. That list
+// was never stored, so those scores cannot be rekeyed: imports keep them as
+// they are and set PositionalScores, as the schema migration does for stored
+// games.
+const exportVersion = 2
+
+type exportDoc struct {
+	Version int
+	Exported int64
+	Sessions []exportSession
+	Players []player
+	Boards []board
+	Locations []location
+	HeadToHead []stats.HeadToHead
+}
+
+type exportSession struct {
+	session
+	Games []exportGame
+}
+
+type exportGame struct {
+	game
+	Scores map[int]float32
+}
+
+// exportFilename is the name offered when downloading an export made at t.
+func exportFilename(t time.Time) string {
+	return "boardgame-logbook-" + t.Format("2006-01-02") + ".json"
+}
+
+func exportLogbook(st Store) (exportDoc, error) {
+	var err error
+	Doc := exportDoc{
+		Version: exportVersion,
+		Exported: time.Now().Unix(),
+	}
+	if Doc.Players, err = retrieveAllPlayers(st); err != nil {
+		return Doc, errors.New("error exporting players").Wrap(err)
+	}
+	if Doc.Boards, err = retrieveAllBoards(st); err != nil {
+		return Doc, errors.New("error exporting boards").Wrap(err)
+	}
+	if Doc.Locations, err = retrieveAllLocations(st); err != nil {
+		return Doc, errors.New("error exporting locations").Wrap(err)
+	}
+	Sessions, err := retrieveAllSessions(st)
+	if err != nil {
+		return Doc, errors.New("error exporting sessions").Wrap(err)
+	}
+	Doc.Sessions = make([]exportSession, len(Sessions))
+	for idx, Session := range Sessions {
+		Games, err := retrieveGamesInSession(st, Session.ID)
+		if err != nil {
+			return Doc, errors.Newf("error exporting games for session %v", Session.ID).Wrap(err)
+		}
+		Doc.Sessions[idx] = exportSession{
+			session: Session,
+			Games: make([]exportGame, len(Games)),
+		}
+		for gidx, Game := range Games {
+			Scores, err := retrieveScoresInGameMap(st, Game.ID)
+			if err != nil {
+				return Doc, errors.Newf("error exporting scores for game %v", Game.ID).Wrap(err)
+			}
+			Doc.Sessions[idx].Games[gidx] = exportGame{
+				game: Game,
+				Scores: Scores,
+			}
+		}
+	}
+	Log, err := loadLogbook(st)
+	if err != nil {
+		return Doc, errors.New("error exporting head to head records").Wrap(err)
+	}
+	Doc.HeadToHead = stats.AllHeadToHeads(Log)
+	return Doc, nil
+}
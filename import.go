@@ -0,0 +1,460 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/maxence-charriere/go-app/v7/pkg/errors"
+)
+
+func parseExport(data []byte) (exportDoc, error) {
+	Doc := exportDoc{}
+	if err := json.Unmarshal(data, &Doc); err != nil {
+		return Doc, errors.New("error parsing export").Wrap(err)
+	}
+	if Doc.Version < 1 || Doc.Version > exportVersion {
+		return Doc, errors.Newf("unsupported export version %v", Doc.Version)
+	}
+	if Doc.Version == 1 {
+		// version 1 predates scores keyed by player ID
+		for _, Session := range Doc.Sessions {
+			for idx := range Session.Games {
+				Session.Games[idx].PositionalScores = true
+			}
+		}
+	}
+	return Doc, nil
+}
+
+// importReplace deletes the whole logbook and writes the export in its place,
+// keeping the exported IDs.
+func importReplace(st Store, Doc exportDoc) error {
+	return st.Batch(func(st Store) error {
+		return replaceLogbook(st, Doc)
+	})
+}
+
+func replaceLogbook(st Store, Doc exportDoc) error {
+	if err := st.Clear(); err != nil {
+		return errors.New("error clearing logbook").Wrap(err)
+	}
+	counts := map[string]int{}
+	bump := func(kind string, ID int) {
+		if ID+1 > counts[kind] {
+			counts[kind] = ID + 1
+		}
+	}
+	for _, Player := range Doc.Players {
+		if err := storePlayer(st, Player); err != nil {
+			return err
+		}
+		bump("player", Player.ID)
+	}
+	for _, Board := range Doc.Boards {
+		if err := storeBoard(st, Board); err != nil {
+			return err
+		}
+		bump("board", Board.ID)
+	}
+	for _, Location := range Doc.Locations {
+		if err := storeLocation(st, Location); err != nil {
+			return err
+		}
+		bump("location", Location.ID)
+	}
+	BoardGames := map[int][]int{}
+	for _, Session := range Doc.Sessions {
+		if err := storeSession(st, Session.session); err != nil {
+			return err
+		}
+		bump("session", Session.ID)
+		GameIDs := make([]int, len(Session.Games))
+		for idx, Game := range Session.Games {
+			if err := storeGame(st, Game.game); err != nil {
+				return err
+			}
+			if err := st.SetGameScores(Game.ID, Game.Scores); err != nil {
+				return errors.New("error storing game scores").Wrap(err)
+			}
+			bump("game", Game.ID)
+			GameIDs[idx] = Game.ID
+			BoardGames[Game.Board] = append(BoardGames[Game.Board], Game.ID)
+		}
+		if err := st.SetSessionGames(Session.ID, GameIDs); err != nil {
+			return errors.New("error storing session games").Wrap(err)
+		}
+	}
+	for Board, GameIDs := range BoardGames {
+		if err := st.SetBoardGames(Board, GameIDs); err != nil {
+			return errors.New("error storing board games").Wrap(err)
+		}
+	}
+	for kind, count := range counts {
+		if err := st.SetCount(kind, count); err != nil {
+			return errors.Newf("error storing %v count", kind).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// mergePlan describes how an export folds into the current logbook. It is
+// computed by planMerge without writing anything, so it can be shown to the
+// user before applyMerge is called.
+type mergePlan struct {
+	Doc exportDoc
+
+	// export ID -> local ID
+	Players map[int]int
+	Boards map[int]int
+	Locations map[int]int
+	Sessions map[int]int
+
+	NewPlayers []player
+	NewBoards []board
+	NewLocations []location
+	MatchedPlayers int
+	MatchedBoards int
+
+	NewSessions int
+	// exported sessions taken to be already in the logbook
+	SkippedSessions []exportSession
+	NewGames int
+
+	// counts once the merge is applied
+	Counts map[string]int
+}
+
+// planMerge matches exported players, boards and locations to existing ones
+// by normalized name and gives everything else fresh IDs after the current
+// counts. A session is taken to be already imported, and skipped, when a
+// session of the same date holds each of its games, same board and same
+// players. Other sessions of that day, as recorded on another device, are
+// merged.
+func planMerge(st Store, Doc exportDoc) (mergePlan, error) {
+	Plan := mergePlan{
+		Doc: Doc,
+		Players: map[int]int{},
+		Boards: map[int]int{},
+		Locations: map[int]int{},
+		Sessions: map[int]int{},
+		Counts: map[string]int{},
+	}
+
+	AllPlayers, err := retrieveAllPlayers(st)
+	if err != nil {
+		return Plan, err
+	}
+	playerByName := map[string]int{}
+	for _, Player := range AllPlayers {
+		playerByName[normalizeName(Player.Text)] = Player.ID
+	}
+	nextPlayer, err := getPlayerCount(st)
+	if err != nil {
+		return Plan, err
+	}
+	for _, Player := range Doc.Players {
+		if ID, ok := playerByName[normalizeName(Player.Text)]; ok {
+			Plan.Players[Player.ID] = ID
+			Plan.MatchedPlayers++
+			continue
+		}
+		Plan.Players[Player.ID] = nextPlayer
+		Player.ID = nextPlayer
+		nextPlayer++
+		playerByName[normalizeName(Player.Text)] = Player.ID
+		Plan.NewPlayers = append(Plan.NewPlayers, Player)
+	}
+
+	AllBoards, err := retrieveAllBoards(st)
+	if err != nil {
+		return Plan, err
+	}
+	boardByName := map[string]int{}
+	for _, Board := range AllBoards {
+		boardByName[normalizeName(Board.Text)] = Board.ID
+	}
+	nextBoard, err := getBoardCount(st)
+	if err != nil {
+		return Plan, err
+	}
+	for _, Board := range Doc.Boards {
+		if ID, ok := boardByName[normalizeName(Board.Text)]; ok {
+			Plan.Boards[Board.ID] = ID
+			Plan.MatchedBoards++
+			continue
+		}
+		Plan.Boards[Board.ID] = nextBoard
+		Board.ID = nextBoard
+		nextBoard++
+		boardByName[normalizeName(Board.Text)] = Board.ID
+		Plan.NewBoards = append(Plan.NewBoards, Board)
+	}
+
+	AllLocations, err := retrieveAllLocations(st)
+	if err != nil {
+		return Plan, err
+	}
+	locationByName := map[string]int{}
+	for _, Location := range AllLocations {
+		locationByName[normalizeName(Location.Text)] = Location.ID
+	}
+	nextLocation, err := getLocationCount(st)
+	if err != nil {
+		return Plan, err
+	}
+	for _, Location := range Doc.Locations {
+		if ID, ok := locationByName[normalizeName(Location.Text)]; ok {
+			Plan.Locations[Location.ID] = ID
+			continue
+		}
+		Plan.Locations[Location.ID] = nextLocation
+		Location.ID = nextLocation
+		nextLocation++
+		locationByName[normalizeName(Location.Text)] = Location.ID
+		Plan.NewLocations = append(Plan.NewLocations, Location)
+	}
+
+	AllSessions, err := retrieveAllSessions(st)
+	if err != nil {
+		return Plan, err
+	}
+	known := map[int64][]map[string]int{}
+	for _, Session := range AllSessions {
+		Games, err := retrieveGamesInSession(st, Session.ID)
+		if err != nil {
+			return Plan, err
+		}
+		known[Session.Date] = append(known[Session.Date], gameKeys(Games))
+	}
+	// IDs missing from the export match nothing, applyMerge rejects them
+	local := func(IDs map[int]int, ID int) int {
+		if Local, ok := IDs[ID]; ok {
+			return Local
+		}
+		return -1
+	}
+	nextSession, err := getSessionCount(st)
+	if err != nil {
+		return Plan, err
+	}
+	for _, Session := range Doc.Sessions {
+		Games := make([]game, len(Session.Games))
+		for idx, Game := range Session.Games {
+			Games[idx] = game{Board: local(Plan.Boards, Game.Board), Players: make([]int, len(Game.Players))}
+			for pos, Player := range Game.Players {
+				Games[idx].Players[pos] = local(Plan.Players, Player)
+			}
+		}
+		imported := false
+		for _, Held := range known[Session.Date] {
+			if holdsGames(Held, gameKeys(Games)) {
+				imported = true
+				break
+			}
+		}
+		if imported {
+			Plan.SkippedSessions = append(Plan.SkippedSessions, Session)
+			continue
+		}
+		Plan.Sessions[Session.ID] = nextSession
+		nextSession++
+		Plan.NewSessions++
+		Plan.NewGames += len(Session.Games)
+	}
+
+	Games, err := getGameCount(st)
+	if err != nil {
+		return Plan, err
+	}
+	Plan.Counts["player"] = nextPlayer
+	Plan.Counts["board"] = nextBoard
+	Plan.Counts["location"] = nextLocation
+	Plan.Counts["session"] = nextSession
+	Plan.Counts["game"] = Games + Plan.NewGames
+	return Plan, nil
+}
+
+// gameKeys counts the games of a session by board and players.
+func gameKeys(Games []game) map[string]int {
+	Keys := map[string]int{}
+	for _, Game := range Games {
+		Players := append([]int{}, Game.Players...)
+		sort.Ints(Players)
+		Keys[fmt.Sprint(Game.Board, Players)]++
+	}
+	return Keys
+}
+
+// holdsGames tells whether the games counted in Held include every game
+// counted in Games.
+func holdsGames(Held map[string]int, Games map[string]int) bool {
+	for Key, Count := range Games {
+		if Held[Key] < Count {
+			return false
+		}
+	}
+	return true
+}
+
+// player maps an exported player ID to its local ID. Exports referencing a
+// player they do not list are malformed, and must not end up attached to
+// whichever local player has the zero ID.
+func (p mergePlan) player(ID int) (int, error) {
+	Local, ok := p.Players[ID]
+	if !ok {
+		return 0, errors.Newf("player %v is not in the export", ID)
+	}
+	return Local, nil
+}
+
+func (p mergePlan) board(ID int) (int, error) {
+	Local, ok := p.Boards[ID]
+	if !ok {
+		return 0, errors.Newf("board %v is not in the export", ID)
+	}
+	return Local, nil
+}
+
+func (p mergePlan) location(ID int) (int, error) {
+	Local, ok := p.Locations[ID]
+	if !ok {
+		return 0, errors.Newf("location %v is not in the export", ID)
+	}
+	return Local, nil
+}
+
+// applyMerge writes the records planned by planMerge.
+func applyMerge(st Store, Plan mergePlan) error {
+	return st.Batch(func(st Store) error {
+		return mergeLogbook(st, Plan)
+	})
+}
+
+func mergeLogbook(st Store, Plan mergePlan) error {
+	for _, Player := range Plan.NewPlayers {
+		if err := storePlayer(st, Player); err != nil {
+			return err
+		}
+	}
+	var err error
+	for _, Board := range Plan.NewBoards {
+		if Board.HasBase {
+			if Board.Base, err = Plan.board(Board.Base); err != nil {
+				return err
+			}
+		}
+		if err := storeBoard(st, Board); err != nil {
+			return err
+		}
+	}
+	for _, Location := range Plan.NewLocations {
+		if err := storeLocation(st, Location); err != nil {
+			return err
+		}
+	}
+	nextGame := Plan.Counts["game"] - Plan.NewGames
+	for _, Session := range Plan.Doc.Sessions {
+		ID, ok := Plan.Sessions[Session.ID]
+		if !ok {
+			continue
+		}
+		Session.session.ID = ID
+		if Session.HasLocation {
+			if Session.Location, err = Plan.location(Session.Location); err != nil {
+				return err
+			}
+		}
+		Attendees := make([]int, len(Session.Attendees))
+		for pos, Player := range Session.Attendees {
+			if Attendees[pos], err = Plan.player(Player); err != nil {
+				return err
+			}
+		}
+		Session.Attendees = Attendees
+		if err := storeSession(st, Session.session); err != nil {
+			return err
+		}
+		GameIDs := make([]int, len(Session.Games))
+		for idx, Game := range Session.Games {
+			Game.game.ID = nextGame
+			Game.Session = ID
+			if Game.Board, err = Plan.board(Game.Board); err != nil {
+				return err
+			}
+			Expansions := make([]int, len(Game.Expansions))
+			for pos, Expansion := range Game.Expansions {
+				if Expansions[pos], err = Plan.board(Expansion); err != nil {
+					return err
+				}
+			}
+			Game.Expansions = Expansions
+			Players := make([]int, len(Game.Players))
+			for pos, Player := range Game.Players {
+				if Players[pos], err = Plan.player(Player); err != nil {
+					return err
+				}
+			}
+			Game.Players = Players
+			Game.game = copyGame(Game.game)
+			Breakdown := make(map[int]map[string]float32, len(Game.Breakdown))
+			for Player, Sheet := range Game.Breakdown {
+				Local, err := Plan.player(Player)
+				if err != nil {
+					return err
+				}
+				Breakdown[Local] = Sheet
+			}
+			Game.Breakdown = Breakdown
+			Factions := make(map[int]string, len(Game.Factions))
+			for Player, Faction := range Game.Factions {
+				Local, err := Plan.player(Player)
+				if err != nil {
+					return err
+				}
+				Factions[Local] = Faction
+			}
+			Game.Factions = Factions
+			for _, Team := range Game.Teams {
+				for pos, Player := range Team.Players {
+					if Team.Players[pos], err = Plan.player(Player); err != nil {
+						return err
+					}
+				}
+			}
+			nextGame++
+			if err := storeGame(st, Game.game); err != nil {
+				return err
+			}
+			Scores := make(map[int]float32, len(Game.Scores))
+			for Player, Score := range Game.Scores {
+				if Game.PositionalScores {
+					// keyed by position, not by player
+					Scores[Player] = Score
+					continue
+				}
+				Local, err := Plan.player(Player)
+				if err != nil {
+					return err
+				}
+				Scores[Local] = Score
+			}
+			if err := st.SetGameScores(Game.game.ID, Scores); err != nil {
+				return errors.New("error storing game scores").Wrap(err)
+			}
+			if err := indexBoardGame(st, Game.Board, Game.game.ID); err != nil {
+				return err
+			}
+			GameIDs[idx] = Game.game.ID
+		}
+		if err := st.SetSessionGames(ID, GameIDs); err != nil {
+			return errors.New("error storing session games").Wrap(err)
+		}
+	}
+	for kind, count := range Plan.Counts {
+		if err := st.SetCount(kind, count); err != nil {
+			return errors.Newf("error storing %v count", kind).Wrap(err)
+		}
+	}
+	return nil
+}
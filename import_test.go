@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMergeUnknownPlayer(t *testing.T) {
+	st := newMemoryStore()
+	if _, err := newPlayer(st, "Ann"); err != nil {
+		t.Fatal(err)
+	}
+	Doc := exportDoc{
+		Version: exportVersion,
+		Boards: []board{{ID: 0, Text: "Azul"}},
+		Players: []player{{ID: 0, Text: "Bob"}},
+		Sessions: []exportSession{{
+			session: session{ID: 0, Date: 1600000000},
+			Games: []exportGame{{
+				game: game{ID: 0, Board: 0, Session: 0, Players: []int{0, 5}},
+				// player 5 is not listed in the export
+				Scores: map[int]float32{0: 10, 5: 12},
+			}},
+		}},
+	}
+	Plan, err := planMerge(st, Doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyMerge(st, Plan); err == nil {
+		t.Fatal("applyMerge accepted a score for a player missing from the export")
+	}
+	for _, kind := range []string{"session", "game", "board"} {
+		if count, _ := st.Count(kind); count != 0 {
+			t.Errorf("%v count after failed merge = %v; want 0", kind, count)
+		}
+	}
+	if count, _ := st.Count("player"); count != 1 {
+		t.Errorf("player count after failed merge = %v; want 1", count)
+	}
+}
+
+func TestMergeSameDaySessions(t *testing.T) {
+	st := newMemoryStore()
+	Ann, _ := newPlayer(st, "Ann")
+	Azul, _ := newBoard(st, "Azul")
+	Session, _ := newSession(st)
+	Session.Date = 1600000000
+	if err := storeSession(st, Session); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newGame(st, game{Board: Azul.ID, Session: Session.ID, Players: []int{Ann.ID}},
+		map[int]float32{Ann.ID: 40}); err != nil {
+		t.Fatal(err)
+	}
+
+	// exported from another device: IDs differ, names match
+	Doc := exportDoc{
+		Version: exportVersion,
+		Boards: []board{{ID: 0, Text: "Catan"}, {ID: 1, Text: "Azul"}},
+		Players: []player{{ID: 0, Text: "Bob"}, {ID: 1, Text: "ann"}},
+		Sessions: []exportSession{{
+			// the session above, exported before
+			session: session{ID: 0, Date: 1600000000},
+			Games: []exportGame{{game: game{ID: 0, Board: 1, Session: 0, Players: []int{1}}}},
+		}, {
+			// another session of the same day
+			session: session{ID: 1, Date: 1600000000},
+			Games: []exportGame{{game: game{ID: 1, Board: 0, Session: 1, Players: []int{0, 1}}}},
+		}},
+	}
+	Plan, err := planMerge(st, Doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Plan.NewSessions != 1 || Plan.NewGames != 1 {
+		t.Errorf("new sessions %v with %v games; want 1 with 1", Plan.NewSessions, Plan.NewGames)
+	}
+	if len(Plan.SkippedSessions) != 1 || Plan.SkippedSessions[0].ID != 0 {
+		t.Errorf("skipped sessions = %+v; want exported session 0", Plan.SkippedSessions)
+	}
+	if err := applyMerge(st, Plan); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := st.Count("session"); count != 2 {
+		t.Errorf("session count = %v; want 2", count)
+	}
+}
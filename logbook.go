@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Textualization/boardgame-logbook/stats"
+)
+
+// formatDuration shows a play time in hours and minutes, as in "2h05m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh%02dm", h, m)
+}
+
+// comboName names a combo, as in "Catan + Seafarers".
+func comboName(Combo []int, Boards []board) string {
+	names := make([]string, len(Combo))
+	for idx, ID := range Combo {
+		names[idx] = findBoard(Boards, ID).Text
+	}
+	return strings.Join(names, " + ")
+}
+
+// loadLogbook reads the sessions, their games and the game scores.
+func loadLogbook(st Store) (stats.Logbook, error) {
+	Log := stats.Logbook{
+		Sessions: map[int]session{},
+		Scores: map[int]map[int]float32{},
+		Boards: map[int]board{},
+	}
+	Sessions, err := retrieveAllSessions(st)
+	if err != nil {
+		return Log, err
+	}
+	sort.SliceStable(Sessions, func(i, j int) bool {
+		return Sessions[i].Date < Sessions[j].Date
+	})
+	for _, Session := range Sessions {
+		Log.Sessions[Session.ID] = Session
+		Games, err := retrieveGamesInSession(st, Session.ID)
+		if err != nil {
+			return Log, err
+		}
+		for _, Game := range Games {
+			if Log.Scores[Game.ID], err = retrieveScoresInGameMap(st, Game.ID); err != nil {
+				return Log, err
+			}
+			Log.Games = append(Log.Games, Game)
+		}
+	}
+	Boards, err := retrieveAllBoards(st)
+	if err != nil {
+		return Log, err
+	}
+	for _, Board := range Boards {
+		Log.Boards[Board.ID] = Board
+	}
+	if Log.Players, err = retrieveAllPlayers(st); err != nil {
+		return Log, err
+	}
+	return Log, nil
+}
+
+// loadBoardLogbook reads only the games of a board, through its list of
+// games, in the order they were played.
+func loadBoardLogbook(st Store, Board int) (stats.Logbook, error) {
+	Log := stats.Logbook{
+		Sessions: map[int]session{},
+		Scores: map[int]map[int]float32{},
+		Boards: map[int]board{},
+	}
+	Games, err := retrieveGamesOfBoard(st, Board)
+	if err != nil {
+		return Log, err
+	}
+	for _, Game := range Games {
+		if _, ok := Log.Sessions[Game.Session]; !ok {
+			if Log.Sessions[Game.Session], err = retrieveSession(st, Game.Session); err != nil {
+				return Log, err
+			}
+		}
+		if Log.Scores[Game.ID], err = retrieveScoresInGameMap(st, Game.ID); err != nil {
+			return Log, err
+		}
+	}
+	sort.SliceStable(Games, func(i, j int) bool {
+		Di, Dj := Log.Sessions[Games[i].Session].Date, Log.Sessions[Games[j].Session].Date
+		if Di != Dj {
+			return Di < Dj
+		}
+		return Games[i].ID < Games[j].ID
+	})
+	Log.Games = Games
+	Boards, err := retrieveAllBoards(st)
+	if err != nil {
+		return Log, err
+	}
+	for _, Board := range Boards {
+		Log.Boards[Board.ID] = Board
+	}
+	if Log.Players, err = retrieveAllPlayers(st); err != nil {
+		return Log, err
+	}
+	return Log, nil
+}
+
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadLogbook(t *testing.T) {
+	st := newMemoryStore()
+	Ann, _ := newPlayer(st, "Ann")
+	Catan, _ := newBoard(st, "Catan")
+	Azul, _ := newBoard(st, "Azul")
+	// session 0 was played after session 1
+	Dates := []int64{1600100000, 1600000000}
+	for _, Date := range Dates {
+		Session, err := newSession(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Session.Date = Date
+		if err := storeSession(st, Session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, Game := range []game{
+		{Board: Catan.ID, Session: 0, Players: []int{Ann.ID}},
+		{Board: Azul.ID, Session: 1, Players: []int{Ann.ID}},
+		{Board: Catan.ID, Session: 1, Players: []int{Ann.ID}},
+	} {
+		if _, err := newGame(st, Game, map[int]float32{Ann.ID: float32(Game.Board + 10)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Log, err := loadLogbook(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	IDs := make([]int, len(Log.Games))
+	for idx, Game := range Log.Games {
+		IDs[idx] = Game.ID
+	}
+	if !reflect.DeepEqual(IDs, []int{1, 2, 0}) {
+		t.Errorf("games = %v; want [1 2 0], by session date then position", IDs)
+	}
+	if Log.Scores[1][Ann.ID] != 11 || len(Log.Boards) != 2 || len(Log.Players) != 1 {
+		t.Errorf("logbook = %+v; want the scores, boards and players", Log)
+	}
+
+	Log, err = loadBoardLogbook(st, Catan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	IDs = IDs[:0]
+	for _, Game := range Log.Games {
+		IDs = append(IDs, Game.ID)
+	}
+	if !reflect.DeepEqual(IDs, []int{2, 0}) || len(Log.Sessions) != 2 {
+		t.Errorf("Catan games = %v in %v sessions; want [2 0] in 2", IDs, len(Log.Sessions))
+	}
+}
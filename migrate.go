@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/maxence-charriere/go-app/v7/pkg/app"
+	"github.com/maxence-charriere/go-app/v7/pkg/errors"
+)
+
+// The layout version of the stored records is kept under schemaVersionKey.
+// Storages written before versioning have no such key and are version 0.
+const schemaVersionKey = "schema-version"
+
+// migration upgrades the stored records from Version-1 to Version. It works
+// on the raw browser storage, as the records it reads are in an older layout
+// than the current structs.
+type migration struct {
+	Version int
+	Name string
+	Run func(kv app.BrowserStorage) error
+}
+
+// migrations must be kept ordered by Version, without gaps. Append new ones at
+// the end and never change one that has shipped.
+var migrations = []migration{
+	{
+		Version: 1,
+		Name: "add schema version",
+		Run: func(kv app.BrowserStorage) error { return nil },
+	},
+	{
+		Version: 2,
+		Name: "flag scores keyed by position",
+		Run: flagPositionalScores,
+	},
+	{
+		Version: 3,
+		Name: "index games by board",
+		Run: indexGamesByBoard,
+	},
+}
+
+// schemaVersion is the layout this build reads and writes.
+func schemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+func getSchemaVersion(kv app.BrowserStorage) (int, error) {
+	version := 0
+	if err := kv.Get(schemaVersionKey, &version); err != nil {
+		return 0, errors.New("error fetching schema version").Wrap(err)
+	}
+	return version, nil
+}
+
+// migrate brings the storage up to schemaVersion. Each migration is applied
+// together with its version bump, and undone if it fails, so an interrupted
+// upgrade resumes from the last completed step.
+func migrate(kv app.BrowserStorage) error {
+	version, err := getSchemaVersion(kv)
+	if err != nil {
+		return err
+	}
+	if version > schemaVersion() {
+		return errors.Newf("stored schema version %v is newer than this app (%v)", version, schemaVersion())
+	}
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+		j := newJournal(kv)
+		err := m.Run(j)
+		if err == nil {
+			err = j.Set(schemaVersionKey, m.Version)
+		}
+		if err != nil {
+			if rerr := j.rollback(); rerr != nil {
+				app.Log("%s", errors.New("error rolling back migration").Wrap(rerr))
+			}
+			return errors.Newf("error migrating to schema version %v (%v)", m.Version, m.Name).Wrap(err)
+		}
+		app.Log(fmt.Sprintf("migrated to schema version %v: %v", m.Version, m.Name))
+	}
+	return nil
+}
+
+// flagPositionalScores flags the games whose game-N-scores maps were keyed
+// by the position in the score entry form instead of by player ID. It does
+// not repair them: those games never stored their players nor their order,
+// so there is nothing to rekey the scores by, and any guess would credit
+// scores to the wrong players. Flagging is the only safe option. The scores
+// are left as they are, with PositionalScores set for the pages to warn
+// about and the statistics to leave out, until the players are picked again
+// on the edit page. Games that list their players were recorded by ID and
+// are not touched.
+func flagPositionalScores(kv app.BrowserStorage) error {
+	count := 0
+	if err := kv.Get("game-count", &count); err != nil {
+		return errors.New("error fetching game count").Wrap(err)
+	}
+	for ID := 0; ID < count; ID++ {
+		key := fmt.Sprintf("game-%v", ID)
+		Game := map[string]json.RawMessage{}
+		if err := kv.Get(key, &Game); err != nil {
+			return errors.Newf("error fetching %v", key).Wrap(err)
+		}
+		if len(Game) == 0 {
+			continue
+		}
+		Players := []int{}
+		if raw, ok := Game["Players"]; ok {
+			if err := json.Unmarshal(raw, &Players); err != nil {
+				return errors.Newf("error reading players of %v", key).Wrap(err)
+			}
+		}
+		if len(Players) > 0 {
+			continue
+		}
+		Game["PositionalScores"] = json.RawMessage("true")
+		if err := kv.Set(key, Game); err != nil {
+			return errors.Newf("error storing %v", key).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// indexGamesByBoard writes the board-N-games lists, in game ID order.
+func indexGamesByBoard(kv app.BrowserStorage) error {
+	count := 0
+	if err := kv.Get("game-count", &count); err != nil {
+		return errors.New("error fetching game count").Wrap(err)
+	}
+	BoardGames := map[int][]int{}
+	for ID := 0; ID < count; ID++ {
+		key := fmt.Sprintf("game-%v", ID)
+		Game := map[string]json.RawMessage{}
+		if err := kv.Get(key, &Game); err != nil {
+			return errors.Newf("error fetching %v", key).Wrap(err)
+		}
+		if len(Game) == 0 {
+			continue
+		}
+		Board := 0
+		if raw, ok := Game["Board"]; ok {
+			if err := json.Unmarshal(raw, &Board); err != nil {
+				return errors.Newf("error reading board of %v", key).Wrap(err)
+			}
+		}
+		BoardGames[Board] = append(BoardGames[Board], ID)
+	}
+	for Board, GameIDs := range BoardGames {
+		key := fmt.Sprintf("board-%v-games", Board)
+		if err := kv.Set(key, GameIDs); err != nil {
+			return errors.Newf("error storing %v", key).Wrap(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maxence-charriere/go-app/v7/pkg/app"
+	"github.com/maxence-charriere/go-app/v7/pkg/errors"
+)
+
+// seedStorage writes raw records into app.LocalStorage, which is kept in
+// memory outside wasm.
+func seedStorage(t *testing.T, records map[string]interface{}) {
+	app.LocalStorage.Clear()
+	for k, v := range records {
+		if err := app.LocalStorage.Set(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// baselineLayout is a logbook as the first release stored it: no schema
+// version, games without players, and scores keyed by the position of the
+// player in the score entry form.
+func baselineLayout() map[string]interface{} {
+	return map[string]interface{}{
+		"player-count": 2,
+		"player-0": map[string]interface{}{"ID": 0, "Text": "Ann", "Hidden": false},
+		"player-1": map[string]interface{}{"ID": 1, "Text": "Bob", "Hidden": false},
+		"board-count": 2,
+		"board-0": map[string]interface{}{"ID": 0, "Text": "Catan", "Hidden": false},
+		"board-1": map[string]interface{}{"ID": 1, "Text": "Azul", "Hidden": false},
+		"session-count": 1,
+		"session-0": map[string]interface{}{"ID": 0, "Date": 1600000000},
+		"session-0-games": []int{0, 1, 2},
+		"game-count": 3,
+		"game-0": map[string]interface{}{"ID": 0, "Board": 0, "Session": 0},
+		"game-0-scores": map[string]float32{"0": 12, "1": 9},
+		"game-1": map[string]interface{}{"ID": 1, "Board": 1, "Session": 0},
+		"game-1-scores": map[string]float32{"1": 40},
+		"game-2": map[string]interface{}{"ID": 2, "Board": 0, "Session": 0},
+		"game-2-scores": map[string]float32{},
+	}
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	seedStorage(t, baselineLayout())
+	defer app.LocalStorage.Clear()
+	if err := migrate(app.LocalStorage); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := getSchemaVersion(app.LocalStorage); version != 3 {
+		t.Errorf("schema version = %v; want 3", version)
+	}
+	st := newLocalStore(app.LocalStorage)
+	for ID := 0; ID < 3; ID++ {
+		Game, err := st.Game(ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Game.PositionalScores {
+			t.Errorf("game %v is not flagged with PositionalScores", ID)
+		}
+	}
+	// the scores stay keyed by position, there is no player order to rekey
+	// them through
+	if Scores, _ := st.GameScores(0); !reflect.DeepEqual(Scores, map[int]float32{0: 12, 1: 9}) {
+		t.Errorf("game 0 scores = %v; want them untouched", Scores)
+	}
+	if Games, _ := st.BoardGames(0); !reflect.DeepEqual(Games, []int{0, 2}) {
+		t.Errorf("board-0-games = %v; want [0 2]", Games)
+	}
+	if Games, _ := st.BoardGames(1); !reflect.DeepEqual(Games, []int{1}) {
+		t.Errorf("board-1-games = %v; want [1]", Games)
+	}
+	if Player, _ := st.Player(1); Player.Text != "Bob" {
+		t.Errorf("player 1 = %+v; want Bob", Player)
+	}
+
+	// running again at the current version changes nothing
+	if err := migrate(app.LocalStorage); err != nil {
+		t.Fatal(err)
+	}
+	if Games, _ := st.BoardGames(0); !reflect.DeepEqual(Games, []int{0, 2}) {
+		t.Errorf("board-0-games after a second migrate = %v; want [0 2]", Games)
+	}
+}
+
+func TestMigrateFlagsOnlyPositionalGames(t *testing.T) {
+	Layout := baselineLayout()
+	Layout[schemaVersionKey] = 1
+	// recorded by player ID: it lists its players
+	Layout["game-1"] = map[string]interface{}{"ID": 1, "Board": 1, "Session": 0, "Players": []int{1}}
+	seedStorage(t, Layout)
+	defer app.LocalStorage.Clear()
+	if err := migrate(app.LocalStorage); err != nil {
+		t.Fatal(err)
+	}
+	st := newLocalStore(app.LocalStorage)
+	if Game, _ := st.Game(0); !Game.PositionalScores {
+		t.Error("game 0 without players is not flagged")
+	}
+	if Game, _ := st.Game(1); Game.PositionalScores || !reflect.DeepEqual(Game.Players, []int{1}) {
+		t.Errorf("game 1 = %+v; want it untouched", Game)
+	}
+	if Scores, _ := st.GameScores(1); !reflect.DeepEqual(Scores, map[int]float32{1: 40}) {
+		t.Errorf("game 1 scores = %v; want them untouched", Scores)
+	}
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+	seedStorage(t, map[string]interface{}{schemaVersionKey: schemaVersion() + 1})
+	defer app.LocalStorage.Clear()
+	if err := migrate(app.LocalStorage); err == nil {
+		t.Error("migrate accepted a schema version newer than the app")
+	}
+}
+
+func TestMigrateRollback(t *testing.T) {
+	seedStorage(t, baselineLayout())
+	defer app.LocalStorage.Clear()
+	shipped := migrations
+	defer func() { migrations = shipped }()
+	migrations = append(append([]migration{}, shipped...), migration{
+		Version: schemaVersion() + 1,
+		Name: "failing",
+		Run: func(kv app.BrowserStorage) error {
+			if err := kv.Set("game-0", map[string]interface{}{"ID": 0, "Board": 1}); err != nil {
+				return err
+			}
+			if err := kv.Set("new-key", 1); err != nil {
+				return err
+			}
+			kv.Del("player-1")
+			return errors.New("failing on purpose")
+		},
+	})
+
+	if err := migrate(app.LocalStorage); err == nil {
+		t.Fatal("migrate did not report the failing migration")
+	}
+	// the migrations before the failing one are kept
+	if version, _ := getSchemaVersion(app.LocalStorage); version != len(shipped) {
+		t.Errorf("schema version = %v; want %v", version, len(shipped))
+	}
+	st := newLocalStore(app.LocalStorage)
+	if Game, _ := st.Game(0); Game.Board != 0 || !Game.PositionalScores {
+		t.Errorf("game 0 = %+v; want the record from before the failing migration", Game)
+	}
+	var created int
+	if err := app.LocalStorage.Get("new-key", &created); err != nil || created != 0 {
+		t.Errorf("new-key = %v, %v; want it removed", created, err)
+	}
+	if Player, _ := st.Player(1); Player.Text != "Bob" {
+		t.Errorf("player 1 = %+v; want it restored", Player)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accents folds the accented letters commonly found in names to ASCII.
+var accents = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ą': "a",
+	'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'œ': "oe",
+	'ř': "r",
+	'ś': "s", 'š': "s", 'ş': "s",
+	'ß': "ss",
+	'ť': "t", 'ţ': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// normalizeName lowercases a player or board name, folds its accents and
+// collapses its whitespace, so "  Café  Bob" and "cafe bob" compare equal.
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(name) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		for _, r := range word {
+			r = unicode.ToLower(r)
+			if folded, ok := accents[r]; ok {
+				b.WriteString(folded)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// editDistance is the Levenshtein distance between two strings, in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// maxTypos is how many edits apart two normalized names of that length can
+// be and still be taken for the same name.
+func maxTypos(name string) int {
+	switch n := len([]rune(name)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// similarNames returns the positions in names that look like the same name as
+// input: the same once normalized first, then the ones a few typos away.
+func similarNames(input string, names []string) []int {
+	norm := normalizeName(input)
+	if len(norm) == 0 {
+		return nil
+	}
+	exact := make([]int, 0)
+	near := make([]int, 0)
+	for idx, name := range names {
+		other := normalizeName(name)
+		if other == norm {
+			exact = append(exact, idx)
+		} else if editDistance(other, norm) <= maxTypos(norm) {
+			near = append(near, idx)
+		}
+	}
+	return append(exact, near...)
+}
+
+func similarPlayers(input string, Players []player) []player {
+	names := make([]string, len(Players))
+	for idx, Player := range Players {
+		names[idx] = Player.Text
+	}
+	Similar := make([]player, 0)
+	for _, idx := range similarNames(input, names) {
+		Similar = append(Similar, Players[idx])
+	}
+	return Similar
+}
+
+func similarBoards(input string, Boards []board) []board {
+	names := make([]string, len(Boards))
+	for idx, Board := range Boards {
+		names[idx] = Board.Text
+	}
+	Similar := make([]board, 0)
+	for _, idx := range similarNames(input, names) {
+		Similar = append(Similar, Boards[idx])
+	}
+	return Similar
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Bob", "bob"},
+		{"  bob ", "bob"},
+		{"Catan ", "catan"},
+		{"Ticket  to\tRide", "ticket to ride"},
+		{"Élodie", "elodie"},
+		{"  Café  Bob", "cafe bob"},
+		{"Straße", "strasse"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := normalizeName(test.name); got != test.want {
+			t.Errorf("normalizeName(%q) = %q; want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"bob", "", 3},
+		{"bob", "bob", 0},
+		{"bob", "rob", 1},
+		{"catan", "catn", 1},
+		{"kitten", "sitting", 3},
+		{"élodie", "elodie", 1},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %v; want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	names := []string{"Bob", "Rob", "Élodie", "Catan", "Catan: Seafarers", "Carcassonne", "bob "}
+	tests := []struct {
+		input string
+		want []int
+	}{
+		// exact matches once normalized come first, in list order
+		{"BOB", []int{0, 6}},
+		{"elodie", []int{2}},
+		{"Elodei", nil},
+		{"Elodi", []int{2}},
+		// short names only match exactly
+		{"Bo", nil},
+		{"Rab", nil},
+		// one typo allowed up to six letters, two beyond
+		{"Catn", []int{3}},
+		{"Catann", []int{3}},
+		{"Carcasone", []int{5}},
+		{"Karkasone", nil},
+		// near matches follow the exact ones
+		{"catan", []int{3}},
+		{"   ", nil},
+	}
+	for _, test := range tests {
+		got := similarNames(test.input, names)
+		if len(got) == 0 && len(test.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("similarNames(%q) = %v; want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestSimilarNamesOrder(t *testing.T) {
+	Players := []player{{ID: 0, Text: "Anna"}, {ID: 1, Text: "anne"}, {ID: 2, Text: "Ann"}, {ID: 3, Text: "ANNE"}}
+	got := similarPlayers("Anne", Players)
+	want := []player{Players[1], Players[3], Players[0], Players[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("similarPlayers(Anne) = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Textualization/boardgame-logbook/stats"
+)
+
+// ratingSystem is how ratings are computed from the recorded games.
+type ratingSystem int
+
+const (
+	// updated after every game
+	Elo ratingSystem = iota
+	// updated after every session, with a deviation telling how sure the
+	// rating is
+	Glicko2
+)
+
+var ratingSystems = []ratingSystem{Elo, Glicko2}
+
+func (r ratingSystem) String() string {
+	switch r {
+	case Glicko2:
+		return "Glicko-2"
+	default:
+		return "Elo"
+	}
+}
+
+const (
+	initialRating = 1500
+	// how much one game can move an Elo rating
+	eloK = 32
+
+	initialDeviation = 350
+	initialVolatility = 0.06
+	// constrains the change in volatility over time
+	glickoTau = 0.5
+	// between the Glicko and the Glicko-2 scales
+	glickoScale = 173.7178
+)
+
+// rating is where a player stands. Deviation and Volatility are only used by
+// Glicko-2.
+type rating struct {
+	Player int
+	Rating float64
+	Deviation float64
+	Volatility float64
+	Games int
+}
+
+// ratingPoint is the rating of a player after a game, or a session for
+// Glicko-2.
+type ratingPoint struct {
+	Date int64
+	Rating float64
+}
+
+// ratings are the current ratings and their history, by player ID.
+type ratings struct {
+	System ratingSystem
+	Ratings map[int]rating
+	History map[int][]ratingPoint
+}
+
+// leaderboard orders the rated players from the highest rating down.
+func (r ratings) leaderboard() []rating {
+	Board := make([]rating, 0, len(r.Ratings))
+	for _, Rating := range r.Ratings {
+		Board = append(Board, Rating)
+	}
+	sort.SliceStable(Board, func(i, j int) bool {
+		if Board[i].Rating != Board[j].Rating {
+			return Board[i].Rating > Board[j].Rating
+		}
+		return Board[i].Player < Board[j].Player
+	})
+	return Board
+}
+
+// result is one player against another in a game: 1 for a win, 0.5 for a tie
+// and 0 for a loss.
+type result struct {
+	Player int
+	Opponent int
+	Score float64
+}
+
+// pairwiseResults splits a game in results between every two players who did
+// not play in the same team, from their placements. Cooperative games and
+// games with positional scores have no such results.
+func pairwiseResults(l stats.Logbook, Game game) []result {
+	if l.Boards[Game.Board].Scoring == stats.Cooperative || Game.PositionalScores {
+		return nil
+	}
+	Places := l.Placements(Game)
+	Results := make([]result, 0)
+	for _, a := range Places {
+		for _, b := range Places {
+			if a.Player == b.Player || (len(a.Team) > 0 && a.Team == b.Team) {
+				continue
+			}
+			Score := 0.5
+			if a.Place < b.Place {
+				Score = 1
+			} else if a.Place > b.Place {
+				Score = 0
+			}
+			Results = append(Results, result{Player: a.Player, Opponent: b.Player, Score: Score})
+		}
+	}
+	return Results
+}
+
+// computeRatings replays the games of the logbook in the order they were
+// played. Board limits the ratings to the games of one board, -1 for all.
+func computeRatings(l stats.Logbook, System ratingSystem, Board int) ratings {
+	Games := make([]game, 0, len(l.Games))
+	for _, Game := range l.Games {
+		if Board < 0 || Game.Board == Board {
+			Games = append(Games, Game)
+		}
+	}
+	if System == Glicko2 {
+		return glicko2Ratings(l, Games)
+	}
+	return eloRatings(l, Games)
+}
+
+func eloRatings(l stats.Logbook, Games []game) ratings {
+	R := ratings{System: Elo, Ratings: map[int]rating{}, History: map[int][]ratingPoint{}}
+	get := func(Player int) rating {
+		if Rating, ok := R.Ratings[Player]; ok {
+			return Rating
+		}
+		return rating{Player: Player, Rating: initialRating}
+	}
+	for _, Game := range Games {
+		Results := pairwiseResults(l, Game)
+		if len(Results) == 0 {
+			continue
+		}
+		// every player meets each opponent for a share of K, against the
+		// ratings from before the game
+		opponents := map[int]int{}
+		for _, Result := range Results {
+			opponents[Result.Player]++
+		}
+		delta := map[int]float64{}
+		for _, Result := range Results {
+			Expected := 1 / (1 + math.Pow(10, (get(Result.Opponent).Rating - get(Result.Player).Rating) / 400))
+			delta[Result.Player] += eloK / float64(opponents[Result.Player]) * (Result.Score - Expected)
+		}
+		Date := l.Sessions[Game.Session].Date
+		for _, Player := range sortedPlayers(opponents) {
+			Rating := get(Player)
+			Rating.Rating += delta[Player]
+			Rating.Games++
+			R.Ratings[Player] = Rating
+			R.History[Player] = append(R.History[Player], ratingPoint{Date: Date, Rating: Rating.Rating})
+		}
+	}
+	return R
+}
+
+// glicko2Ratings takes each session as a rating period, following Glickman's
+// "Example of the Glicko-2 system".
+func glicko2Ratings(l stats.Logbook, Games []game) ratings {
+	R := ratings{System: Glicko2, Ratings: map[int]rating{}, History: map[int][]ratingPoint{}}
+	for start := 0; start < len(Games); {
+		end := start
+		for end < len(Games) && Games[end].Session == Games[start].Session {
+			end++
+		}
+		Results := make([]result, 0)
+		games := map[int]int{}
+		for _, Game := range Games[start:end] {
+			played := map[int]bool{}
+			for _, Result := range pairwiseResults(l, Game) {
+				Results = append(Results, Result)
+				played[Result.Player] = true
+			}
+			for Player := range played {
+				games[Player]++
+			}
+		}
+		Date := l.Sessions[Games[start].Session].Date
+		start = end
+		if len(Results) == 0 {
+			continue
+		}
+
+		// newcomers start from the initial rating; everybody rated so far is
+		// in the period, playing or not
+		for Player := range games {
+			if _, ok := R.Ratings[Player]; !ok {
+				R.Ratings[Player] = rating{
+					Player: Player,
+					Rating: initialRating,
+					Deviation: initialDeviation,
+					Volatility: initialVolatility,
+				}
+			}
+		}
+		byPlayer := map[int][]result{}
+		for _, Result := range Results {
+			byPlayer[Result.Player] = append(byPlayer[Result.Player], Result)
+		}
+		Updated := make(map[int]rating, len(R.Ratings))
+		for Player, Rating := range R.Ratings {
+			Updated[Player] = glicko2Update(Rating, byPlayer[Player], R.Ratings)
+		}
+		for _, Player := range sortedPlayers(games) {
+			Rating := Updated[Player]
+			Rating.Games += games[Player]
+			Updated[Player] = Rating
+			R.History[Player] = append(R.History[Player], ratingPoint{Date: Date, Rating: Rating.Rating})
+		}
+		R.Ratings = Updated
+	}
+	return R
+}
+
+// glicko2Update rates a player after a period with the given results, against
+// the ratings from before the period.
+func glicko2Update(Rating rating, Results []result, Ratings map[int]rating) rating {
+	mu := (Rating.Rating - initialRating) / glickoScale
+	phi := Rating.Deviation / glickoScale
+	sigma := Rating.Volatility
+	if len(Results) == 0 {
+		// not playing only makes the rating less certain, but never less
+		// than that of a newcomer
+		Rating.Deviation = math.Min(math.Sqrt(phi * phi + sigma * sigma) * glickoScale, initialDeviation)
+		return Rating
+	}
+
+	g := func(phi float64) float64 {
+		return 1 / math.Sqrt(1 + 3 * phi * phi / (math.Pi * math.Pi))
+	}
+	var vInv, sum float64
+	for _, Result := range Results {
+		Opponent := Ratings[Result.Opponent]
+		muJ := (Opponent.Rating - initialRating) / glickoScale
+		gJ := g(Opponent.Deviation / glickoScale)
+		E := 1 / (1 + math.Exp(-gJ * (mu - muJ)))
+		vInv += gJ * gJ * E * (1 - E)
+		sum += gJ * (Result.Score - E)
+	}
+	v := 1 / vInv
+	delta := v * sum
+
+	// new volatility, by the Illinois algorithm
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		return ex * (delta * delta - phi * phi - v - ex) / (2 * math.Pow(phi * phi + v + ex, 2)) - (x - a) / (glickoTau * glickoTau)
+	}
+	A := a
+	var B float64
+	if delta * delta > phi * phi + v {
+		B = math.Log(delta * delta - phi * phi - v)
+	} else {
+		k := 1.0
+		for f(a - k * glickoTau) < 0 {
+			k++
+		}
+		B = a - k * glickoTau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B - A) > 0.000001 {
+		C := A + (A - B) * fA / (fB - fA)
+		fC := f(C)
+		if fC * fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	sigma = math.Exp(A / 2)
+
+	phiStar := math.Sqrt(phi * phi + sigma * sigma)
+	phi = 1 / math.Sqrt(1 / (phiStar * phiStar) + 1 / v)
+	mu += phi * phi * sum
+
+	Rating.Rating = mu * glickoScale + initialRating
+	Rating.Deviation = phi * glickoScale
+	Rating.Volatility = sigma
+	return Rating
+}
+
+// sortedPlayers lists the player IDs keying the map in increasing order, so
+// ratings are replayed the same way every time.
+func sortedPlayers(m map[int]int) []int {
+	Players := make([]int, 0, len(m))
+	for Player := range m {
+		Players = append(Players, Player)
+	}
+	sort.Ints(Players)
+	return Players
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Textualization/boardgame-logbook/stats"
+)
+
+const (
+	Ann = iota
+	Bob
+	Cid
+	Dee
+)
+
+// ratingsLogbook has a three player game with a tie, a team game, and a
+// cooperative and a legacy game that leave the ratings alone.
+func ratingsLogbook() stats.Logbook {
+	return stats.Logbook{
+		Sessions: map[int]session{
+			0: {ID: 0, Date: 1600000000},
+			1: {ID: 1, Date: 1600100000},
+		},
+		Games: []game{
+			// Ann 1st, Bob and Cid share 2nd
+			{ID: 0, Board: 0, Session: 0, Players: []int{Ann, Bob, Cid}},
+			// Ann and Bob beat Cid and Dee
+			{ID: 1, Board: 0, Session: 1, Players: []int{Ann, Bob, Cid, Dee}, Teams: []team{
+				{Name: "Red", Players: []int{Ann, Bob}, Score: 1},
+				{Name: "Blue", Players: []int{Cid, Dee}, Score: 0},
+			}},
+			{ID: 2, Board: 1, Session: 1, Players: []int{Ann, Dee}, Won: true},
+			{ID: 3, Board: 0, Session: 1, PositionalScores: true},
+		},
+		Scores: map[int]map[int]float32{
+			0: {Ann: 10, Bob: 8, Cid: 8},
+			1: {Ann: 1, Bob: 1, Cid: 0, Dee: 0},
+			2: {Ann: 0, Dee: 0},
+			3: {0: 100, 1: 50},
+		},
+		Boards: map[int]board{
+			0: {ID: 0, Text: "Catan", Scoring: stats.HighestWins},
+			1: {ID: 1, Text: "Pandemic", Scoring: stats.Cooperative},
+		},
+		Players: []player{{ID: Ann, Text: "Ann"}, {ID: Bob, Text: "Bob"}, {ID: Cid, Text: "Cid"}, {ID: Dee, Text: "Dee"}},
+	}
+}
+
+func TestPairwiseResults(t *testing.T) {
+	Log := ratingsLogbook()
+	Results := pairwiseResults(Log, Log.Games[0])
+	want := map[[2]int]float64{
+		{Ann, Bob}: 1, {Ann, Cid}: 1,
+		{Bob, Ann}: 0, {Bob, Cid}: 0.5,
+		{Cid, Ann}: 0, {Cid, Bob}: 0.5,
+	}
+	if len(Results) != len(want) {
+		t.Errorf("results = %v; want %v", Results, want)
+	}
+	for _, Result := range Results {
+		if Score, ok := want[[2]int{Result.Player, Result.Opponent}]; !ok || Score != Result.Score {
+			t.Errorf("%v against %v scored %v; want %v", Result.Player, Result.Opponent, Result.Score, Score)
+		}
+	}
+	// teammates do not play each other
+	if Results := pairwiseResults(Log, Log.Games[1]); len(Results) != 8 {
+		t.Errorf("team game results = %v; want 8, each player against the 2 of the other team", Results)
+	}
+	for _, Game := range Log.Games[2:] {
+		if Results := pairwiseResults(Log, Game); len(Results) != 0 {
+			t.Errorf("game %v results = %v; want none", Game.ID, Results)
+		}
+	}
+}
+
+func TestEloRatings(t *testing.T) {
+	R := computeRatings(ratingsLogbook(), Elo, -1)
+	// after the first game Ann has 1516, Bob and Cid 1492: the tie between
+	// them moves nothing, as their ratings were equal
+	want := map[int]struct {
+		Rating float64
+		Games int
+	}{
+		Ann: {1531.0801034876097, 2},
+		Bob: {1508.1841742594847, 2},
+		Cid: {1476.5517431156293, 2},
+		Dee: {1484.1839791372763, 1},
+	}
+	total := 0.0
+	for Player, w := range want {
+		Rating := R.Ratings[Player]
+		if math.Abs(Rating.Rating - w.Rating) > 1e-6 || Rating.Games != w.Games {
+			t.Errorf("player %v = %.4f after %v games; want %.4f after %v", Player, Rating.Rating, Rating.Games, w.Rating, w.Games)
+		}
+		total += Rating.Rating
+	}
+	// in each game every player met as many opponents, so what one won
+	// the others lost
+	if math.Abs(total - 4 * initialRating) > 1e-6 {
+		t.Errorf("ratings add up to %v; want %v", total, 4 * initialRating)
+	}
+	if History := R.History[Ann]; len(History) != 2 || History[0].Rating != 1516 || History[1].Date != 1600100000 {
+		t.Errorf("history of Ann = %v; want 1516 then the team game", History)
+	}
+	if Board := R.leaderboard(); Board[0].Player != Ann || Board[3].Player != Cid {
+		t.Errorf("leaderboard = %v; want Ann first and Cid last", Board)
+	}
+	// the cooperative board has no ratings of its own
+	if R := computeRatings(ratingsLogbook(), Elo, 1); len(R.Ratings) != 0 {
+		t.Errorf("cooperative board ratings = %v; want none", R.Ratings)
+	}
+}
+
+// Glickman, "Example of the Glicko-2 system": a 1500 player with a deviation
+// of 200 beats a 1400 player and loses to a 1550 and a 1700 player.
+func TestGlicko2Update(t *testing.T) {
+	Ratings := map[int]rating{
+		1: {Player: 1, Rating: 1400, Deviation: 30, Volatility: initialVolatility},
+		2: {Player: 2, Rating: 1550, Deviation: 100, Volatility: initialVolatility},
+		3: {Player: 3, Rating: 1700, Deviation: 300, Volatility: initialVolatility},
+	}
+	Player := rating{Player: 0, Rating: 1500, Deviation: 200, Volatility: initialVolatility}
+	Results := []result{
+		{Player: 0, Opponent: 1, Score: 1},
+		{Player: 0, Opponent: 2, Score: 0},
+		{Player: 0, Opponent: 3, Score: 0},
+	}
+	got := glicko2Update(Player, Results, Ratings)
+	if math.Abs(got.Rating - 1464.06) > 0.01 {
+		t.Errorf("rating = %v; want 1464.06", got.Rating)
+	}
+	if math.Abs(got.Deviation - 151.52) > 0.01 {
+		t.Errorf("deviation = %v; want 151.52", got.Deviation)
+	}
+	if math.Abs(got.Volatility - 0.05999) > 0.00001 {
+		t.Errorf("volatility = %v; want 0.05999", got.Volatility)
+	}
+}
+
+func TestGlicko2Ratings(t *testing.T) {
+	R := computeRatings(ratingsLogbook(), Glicko2, -1)
+	if len(R.Ratings) != 4 {
+		t.Fatalf("ratings = %v; want the 4 players", R.Ratings)
+	}
+	AnnR, CidR := R.Ratings[Ann], R.Ratings[Cid]
+	if AnnR.Rating <= initialRating || CidR.Rating >= initialRating {
+		t.Errorf("Ann %v and Cid %v; want Ann above and Cid below %v", AnnR.Rating, CidR.Rating, initialRating)
+	}
+	if AnnR.Deviation >= initialDeviation || AnnR.Games != 2 {
+		t.Errorf("Ann = %+v; want a smaller deviation after 2 games", AnnR)
+	}
+	// one point per session played
+	if len(R.History[Ann]) != 2 || len(R.History[Dee]) != 1 {
+		t.Errorf("history of Ann %v and Dee %v; want 2 and 1 points", R.History[Ann], R.History[Dee])
+	}
+}
+
+func TestGlicko2IdleDeviation(t *testing.T) {
+	Rating := rating{Player: 0, Rating: 1500, Deviation: 340, Volatility: 0.5}
+	for i := 0; i < 100; i++ {
+		Rating = glicko2Update(Rating, nil, nil)
+	}
+	if Rating.Deviation != initialDeviation || Rating.Rating != 1500 {
+		t.Errorf("after sitting out 100 periods = %+v; want 1500 with deviation %v", Rating, initialDeviation)
+	}
+
+	// Dee plays once, then sits out the sessions Ann and Bob play alone
+	Log := ratingsLogbook()
+	for ID := 2; ID < 50; ID++ {
+		Log.Sessions[ID] = session{ID: ID, Date: 1600000000 + int64(ID) * 100000}
+		Log.Games = append(Log.Games, game{ID: ID + 2, Board: 0, Session: ID, Players: []int{Ann, Bob}})
+		Log.Scores[ID + 2] = map[int]float32{Ann: float32(ID % 2), Bob: float32(1 - ID % 2)}
+	}
+	R := computeRatings(Log, Glicko2, -1)
+	if Dee := R.Ratings[Dee]; Dee.Deviation > initialDeviation || Dee.Games != 1 {
+		t.Errorf("Dee = %+v; want 1 game and a deviation of at most %v", Dee, initialDeviation)
+	}
+}
@@ -2,23 +2,25 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 	
 	"github.com/maxence-charriere/go-app/v7/pkg/app"
 	"github.com/maxence-charriere/go-app/v7/pkg/errors"
-)
 
-type player struct {
-	ID int
-	Text string
-	Hidden bool
-}
+	"github.com/Textualization/boardgame-logbook/stats"
+)
 
-type board struct {
-	ID int
-	Text string
-	Hidden bool
-}
+// The records are defined in the stats package, so the statistics over them
+// can be computed and tested apart from the app and its storage.
+type (
+	player = stats.Player
+	board = stats.Board
+	game = stats.Game
+	team = stats.Team
+	session = stats.Session
+)
 
 type score struct {
 	Player int
@@ -26,117 +28,185 @@ type score struct {
 	Score float32
 }
 
-type game struct {
-	ID int
-	Board int
-	Session int
-}
-
-type session struct {
+// location is a place sessions are played at.
+type location struct {
 	ID int
-	Date int64
+	Text string
+	Hidden bool
 }
 
-func getCount(key string) (int, error) {
-	count := 0
-	if err := app.LocalStorage.Get(key + "-count", &count); err != nil {
+func getCount(st Store, key string) (int, error) {
+	count, err := st.Count(key)
+	if err != nil {
 		return 0, errors.Newf("error fetching %v count", key).Wrap(err)
 	}
 	return count, nil
 }
 
-func getSessionCount() (int, error) { return getCount("session") }
-func getPlayerCount() (int, error) { return getCount("player") }
-func getBoardCount() (int, error) { return getCount("board") }
-func getGameCount() (int, error) { return getCount("game") }
+func getSessionCount(st Store) (int, error) { return getCount(st, "session") }
+func getPlayerCount(st Store) (int, error) { return getCount(st, "player") }
+func getBoardCount(st Store) (int, error) { return getCount(st, "board") }
+func getGameCount(st Store) (int, error) { return getCount(st, "game") }
+func getLocationCount(st Store) (int, error) { return getCount(st, "location") }
 
-func incCount(key string) (int, error) {
-	count, err := getCount(key)
+func incCount(st Store, key string) (int, error) {
+	count, err := getCount(st, key)
 	if err != nil {
 		return count, err
 	}
-	if err = app.LocalStorage.Set(key + "-count", count + 1); err != nil {
+	if err = st.SetCount(key, count + 1); err != nil {
 		return 0, errors.Newf("error increasing %v count", key).Wrap(err)
 	}
 	return count, nil
 }
 
-func incSessionCount() (int, error) { return incCount("session") }
-func incPlayerCount()  (int, error) { return incCount("player") }
-func incBoardCount()   (int, error) { return incCount("board") }
-func incGameCount()    (int, error) { return incCount("game") }
+func incSessionCount(st Store) (int, error) { return incCount(st, "session") }
+func incPlayerCount(st Store)  (int, error) { return incCount(st, "player") }
+func incBoardCount(st Store)   (int, error) { return incCount(st, "board") }
+func incGameCount(st Store)    (int, error) { return incCount(st, "game") }
+func incLocationCount(st Store) (int, error) { return incCount(st, "location") }
 
-func newSession() (session, error) {
+func newSession(st Store) (session, error) {
 	currentTime := time.Now().Unix()
 	
-	sessionID, err := incSessionCount()
+	Session := session{}
+	err := st.Batch(func(st Store) error {
+		sessionID, err := incSessionCount(st)
+		if err != nil {
+			return errors.New("error storing session count").Wrap(err)
+		}
+		Session = session {
+			ID: sessionID,
+			Date: currentTime,
+		}
+		return storeSession(st, Session)
+	})
+	return Session, err
+}
+
+// deleteSession deletes the session together with all its games.
+func deleteSession(st Store, ID int) error {
+	GameIDs, err := st.SessionGames(ID)
 	if err != nil {
-		return session{}, errors.New("error storing session count").Wrap(err)
+		return errors.New("error fetching session games").Wrap(err)
+	}
+	return st.Batch(func(st Store) error {
+		for _, Game := range GameIDs {
+			Deleted, err := retrieveGame(st, Game)
+			if err != nil {
+				return err
+			}
+			if err := unindexBoardGame(st, Deleted.Board, Game); err != nil {
+				return err
+			}
+			if err := st.DelGame(Game); err != nil {
+				return errors.Newf("error deleting game %v", Game).Wrap(err)
+			}
+		}
+		if err := st.DelSession(ID); err != nil {
+			return errors.Newf("error deleting session %v", ID).Wrap(err)
+		}
+		return nil
+	})
+}
+
+// mergeSession moves the games of a session to the end of another one, then
+// deletes the emptied session.
+func mergeSession(st Store, ID int, Into int) error {
+	if ID == Into {
+		return errors.Newf("cannot merge session %v into itself", ID)
 	}
-	Session := session {
-		ID: sessionID,
-		Date: currentTime,
+	Games, err := retrieveGamesInSession(st, ID)
+	if err != nil {
+		return err
 	}
-	return Session, Session.store()
+	return st.Batch(func(st Store) error {
+		IntoGames, err := st.SessionGames(Into)
+		if err != nil {
+			return errors.New("error fetching session games").Wrap(err)
+		}
+		for _, Game := range Games {
+			Game.Session = Into
+			if err := storeGame(st, Game); err != nil {
+				return err
+			}
+			IntoGames = append(IntoGames, Game.ID)
+		}
+		if err := st.SetSessionGames(Into, IntoGames); err != nil {
+			return errors.New("error storing session games").Wrap(err)
+		}
+		if err := st.DelSession(ID); err != nil {
+			return errors.Newf("error deleting session %v", ID).Wrap(err)
+		}
+		return nil
+	})
 }
 
-func (s session) store() error {
-	if err := app.LocalStorage.Set(fmt.Sprintf("session-%v", s.ID), s); err != nil {
+func storeSession(st Store, s session) error {
+	if err := st.SetSession(s); err != nil {
 		return errors.New("error storing session").Wrap(err)
 	}
 	return nil
 }
 
-func retrieveSession(ID int) (session, error) {
-	Session := session{}
-	if err := app.LocalStorage.Get(fmt.Sprintf("session-%v", ID), &Session); err != nil {
+func retrieveSession(st Store, ID int) (session, error) {
+	Session, err := st.Session(ID)
+	if err != nil {
 		return session{}, errors.Newf("error fetching session %v", ID).Wrap(err)
 	}
 	return Session, nil
 }
 
-func retrieveGame(ID int) (game, error) {
-	Game := game{}
-	if err := app.LocalStorage.Get(fmt.Sprintf("game-%v", ID), &Game); err != nil {
+func retrieveGame(st Store, ID int) (game, error) {
+	Game, err := st.Game(ID)
+	if err != nil {
 		return game{}, errors.Newf("error fetching game %v", ID).Wrap(err)
 	}
 	return Game, nil
 }
 
-func retrievePlayer(ID int) (player, error) {
-	Player := player{}
-	if err := app.LocalStorage.Get(fmt.Sprintf("player-%v", ID), &Player); err != nil {
+func retrievePlayer(st Store, ID int) (player, error) {
+	Player, err := st.Player(ID)
+	if err != nil {
 		return player{}, errors.Newf("error fetching player %v", ID).Wrap(err)
 	}
 	return Player, nil
 }
 
-func retrieveBoard(ID int) (board, error) {
-	Board := board{}
-	if err := app.LocalStorage.Get(fmt.Sprintf("board-%v", ID), &Board); err != nil {
+func retrieveLocation(st Store, ID int) (location, error) {
+	Location, err := st.Location(ID)
+	if err != nil {
+		return location{}, errors.Newf("error fetching location %v", ID).Wrap(err)
+	}
+	return Location, nil
+}
+
+func retrieveBoard(st Store, ID int) (board, error) {
+	Board, err := st.Board(ID)
+	if err != nil {
 		return Board, errors.Newf("error fetching board %v",ID).Wrap(err)
 	}
 	return Board, nil
 }
 
-func retrieveGamesInSession(ID int) ([]game, error) {
-	GameIDs := make([]int, 0)
-	if err := app.LocalStorage.Get(fmt.Sprintf("session-%v-games", ID), &GameIDs); err != nil {
+func retrieveGamesInSession(st Store, ID int) ([]game, error) {
+	GameIDs, err := st.SessionGames(ID)
+	if err != nil {
 		return nil, errors.New("error fetching session games").Wrap(err)
 	}
 	Games := make([]game, len(GameIDs))
 
 	for idx, id := range GameIDs {
-		if err := app.LocalStorage.Get(fmt.Sprintf("game-%v", id), &Games[idx]); err != nil {
+		if Games[idx], err = st.Game(id); err != nil {
 			return Games, errors.Newf("error fetching game %v for session %v", id, ID).Wrap(err)
 		}
 	}
 	return Games, nil
 }
 
-func retrieveScoresInGame(ID int) ([]score, error) {
-	ScoreMap := make(map[int]float32)
-	if err := app.LocalStorage.Get(fmt.Sprintf("game-%v-scores", ID), &ScoreMap); err != nil {
+func retrieveScoresInGame(st Store, ID int) ([]score, error) {
+	ScoreMap, err := st.GameScores(ID)
+	if err != nil {
 		return nil, errors.New("error fetching game scores").Wrap(err)
 	}
 	Scores := make([]score, 0)
@@ -155,130 +225,638 @@ func retrieveScoresInGame(ID int) ([]score, error) {
 	return Scores, nil
 }
 
-func retrieveScoresInGameMap(ID int) (map[int]float32, error) {
-	ScoreMap := make(map[int]float32)
-	if err := app.LocalStorage.Get(fmt.Sprintf("game-%v-scores", ID), &ScoreMap); err != nil {
+func retrieveScoresInGameMap(st Store, ID int) (map[int]float32, error) {
+	ScoreMap, err := st.GameScores(ID)
+	if err != nil {
 		return nil, errors.New("error fetching game scores").Wrap(err)
 	}
 	return ScoreMap, nil
 }
 
-func retrieveAllSessions() ([]session, error) {
-	sessions, err := getSessionCount()
+// retrieveAllSessions skips the IDs of deleted sessions, so the position in
+// the result is not the session ID.
+func retrieveAllSessions(st Store) ([]session, error) {
+	sessions, err := getSessionCount(st)
 	if err != nil {
 		return nil, errors.New("error fetching session count").Wrap(err)
 	}
-	AllSessions := make([]session, sessions)
-	for idx, _ := range AllSessions {
-		if AllSessions[idx], err = retrieveSession(idx); err != nil {
-			return AllSessions, errors.Newf("error fetching session %v", idx).Wrap(err)
+	AllSessions := make([]session, 0, sessions)
+	for ID := 0; ID < sessions; ID++ {
+		ok, err := st.Exists("session", ID)
+		if err != nil {
+			return AllSessions, errors.Newf("error checking session %v", ID).Wrap(err)
+		}
+		if !ok {
+			continue
 		}
+		Session, err := retrieveSession(st, ID)
+		if err != nil {
+			return AllSessions, errors.Newf("error fetching session %v", ID).Wrap(err)
+		}
+		AllSessions = append(AllSessions, Session)
 	}
 	return AllSessions, nil
 }
 
-func retrieveAllBoards() ([]board, error) {
-	boards, err := getBoardCount()
+// retrieveAllBoards skips the IDs of boards merged into others.
+func retrieveAllBoards(st Store) ([]board, error) {
+	boards, err := getBoardCount(st)
 	if err != nil {
 		return nil, errors.New("error fetching board count").Wrap(err)
 	}
-	AllBoards := make([]board, boards)
-	for idx, _ := range AllBoards {
-		if AllBoards[idx], err = retrieveBoard(idx); err != nil {
-			return AllBoards, errors.Newf("error fetching board %v", idx).Wrap(err)
+	AllBoards := make([]board, 0, boards)
+	for ID := 0; ID < boards; ID++ {
+		ok, err := st.Exists("board", ID)
+		if err != nil {
+			return AllBoards, errors.Newf("error checking board %v", ID).Wrap(err)
+		}
+		if !ok {
+			continue
+		}
+		Board, err := retrieveBoard(st, ID)
+		if err != nil {
+			return AllBoards, errors.Newf("error fetching board %v", ID).Wrap(err)
 		}
+		AllBoards = append(AllBoards, Board)
 	}
 	return AllBoards, nil
 }
 
-func retrieveAllPlayers() ([]player, error) {
-	players, err := getPlayerCount()
+// retrieveAllPlayers skips the IDs of players merged into others.
+func retrieveAllPlayers(st Store) ([]player, error) {
+	players, err := getPlayerCount(st)
 	if err != nil {
 		return nil, errors.New("error fetching player count").Wrap(err)
 	}
-	AllPlayers := make([]player, players)
-	for idx, _ := range AllPlayers {
-		if AllPlayers[idx], err = retrievePlayer(idx); err != nil {
-			return AllPlayers, errors.Newf("error fetching player %v", idx).Wrap(err)
+	AllPlayers := make([]player, 0, players)
+	for ID := 0; ID < players; ID++ {
+		ok, err := st.Exists("player", ID)
+		if err != nil {
+			return AllPlayers, errors.Newf("error checking player %v", ID).Wrap(err)
 		}
+		if !ok {
+			continue
+		}
+		Player, err := retrievePlayer(st, ID)
+		if err != nil {
+			return AllPlayers, errors.Newf("error fetching player %v", ID).Wrap(err)
+		}
+		AllPlayers = append(AllPlayers, Player)
 	}
 	return AllPlayers, nil
 }
 
-func newBoard(text string) (board, error) {
-	ID, err := incBoardCount()
+// retrieveAllGames skips the IDs of deleted games.
+func retrieveAllGames(st Store) ([]game, error) {
+	games, err := getGameCount(st)
 	if err != nil {
-		return board{}, err
+		return nil, errors.New("error fetching game count").Wrap(err)
+	}
+	AllGames := make([]game, 0, games)
+	for ID := 0; ID < games; ID++ {
+		ok, err := st.Exists("game", ID)
+		if err != nil {
+			return AllGames, errors.Newf("error checking game %v", ID).Wrap(err)
+		}
+		if !ok {
+			continue
+		}
+		Game, err := retrieveGame(st, ID)
+		if err != nil {
+			return AllGames, err
+		}
+		AllGames = append(AllGames, Game)
+	}
+	return AllGames, nil
+}
+
+func findBoard(Boards []board, ID int) board {
+	for _, Board := range Boards {
+		if Board.ID == ID {
+			return Board
+		}
+	}
+	return board{ID: ID}
+}
+
+// addonsOf lists the expansions and variants of a board.
+func addonsOf(Boards []board, Base int) []board {
+	Addons := make([]board, 0)
+	for _, Board := range Boards {
+		if Board.HasBase && Board.Base == Base {
+			Addons = append(Addons, Board)
+		}
+	}
+	return Addons
+}
+
+func hasExpansion(Game game, Board int) bool {
+	for _, Expansion := range Game.Expansions {
+		if Expansion == Board {
+			return true
+		}
 	}
-	Board := board{
-		ID: ID,
-		Text: text,
+	return false
+}
+
+func findPlayer(Players []player, ID int) player {
+	for _, Player := range Players {
+		if Player.ID == ID {
+			return Player
+		}
 	}
-	return Board, Board.store()
+	return player{ID: ID}
 }
 
-func (b board) store() error {
-	if err := app.LocalStorage.Set(fmt.Sprintf("board-%v", b.ID), b); err != nil {
+func newBoard(st Store, text string) (board, error) {
+	Board := board{}
+	err := st.Batch(func(st Store) error {
+		ID, err := incBoardCount(st)
+		if err != nil {
+			return err
+		}
+		Board = board{
+			ID: ID,
+			Text: strings.TrimSpace(text),
+		}
+		return storeBoard(st, Board)
+	})
+	return Board, err
+}
+
+func storeBoard(st Store, b board) error {
+	if err := st.SetBoard(b); err != nil {
 		return errors.New("error storing board").Wrap(err)
 	}
 	return nil
 }
 
-func newPlayer(text string) (player, error) {
-	ID, err := incPlayerCount()
+func newPlayer(st Store, text string) (player, error) {
+	Player := player{}
+	err := st.Batch(func(st Store) error {
+		ID, err := incPlayerCount(st)
+		if err != nil {
+			return err
+		}
+		Player = player{
+			ID: ID,
+			Text: strings.TrimSpace(text),
+		}
+		return storePlayer(st, Player)
+	})
+	return Player, err
+}
+
+func storePlayer(st Store, p player) error {
+	if err := st.SetPlayer(p); err != nil {
+		return errors.New("error storing player").Wrap(err)
+	}
+	return nil
+}
+
+func newLocation(st Store, text string) (location, error) {
+	Location := location{}
+	err := st.Batch(func(st Store) error {
+		ID, err := incLocationCount(st)
+		if err != nil {
+			return err
+		}
+		Location = location{
+			ID: ID,
+			Text: strings.TrimSpace(text),
+		}
+		return storeLocation(st, Location)
+	})
+	return Location, err
+}
+
+func storeLocation(st Store, l location) error {
+	if err := st.SetLocation(l); err != nil {
+		return errors.New("error storing location").Wrap(err)
+	}
+	return nil
+}
+
+func retrieveAllLocations(st Store) ([]location, error) {
+	locations, err := getLocationCount(st)
 	if err != nil {
-		return player{}, err
+		return nil, err
 	}
-	Player := player{
-		ID: ID,
-		Text: text,
+	AllLocations := make([]location, 0, locations)
+	for ID := 0; ID < locations; ID++ {
+		ok, err := st.Exists("location", ID)
+		if err != nil {
+			return AllLocations, errors.Newf("error checking location %v", ID).Wrap(err)
+		}
+		if !ok {
+			continue
+		}
+		Location, err := retrieveLocation(st, ID)
+		if err != nil {
+			return AllLocations, err
+		}
+		AllLocations = append(AllLocations, Location)
 	}
-	return Player, Player.store()
+	return AllLocations, nil
 }
 
-func (p player) store() error {
-	if err := app.LocalStorage.Set(fmt.Sprintf("player-%v", p.ID), p); err != nil {
-		return errors.New("error storing player").Wrap(err)
+func findLocation(Locations []location, ID int) location {
+	for _, Location := range Locations {
+		if Location.ID == ID {
+			return Location
+		}
 	}
-	return nil
+	return location{}
+}
+
+// newGame records Game, with a fresh ID, in its session. The game, its
+// scores and its place in the session are written as one batch, so a failure
+// leaves no partial game behind.
+func newGame(st Store, Game game, Scores map[int]float32) (game, error) {
+	Game = copyGame(Game)
+	err := st.Batch(func(st Store) error {
+		var err error
+		if Game.ID, err = incGameCount(st); err != nil {
+			return err
+		}
+		if err := storeGame(st, Game); err != nil {
+			return err
+		}
+		gameIDs, err := st.SessionGames(Game.Session)
+		if err != nil {
+			return errors.New("error fetching session games").Wrap(err)
+		}
+		gameIDs = append(gameIDs, Game.ID)
+		if err := st.SetSessionGames(Game.Session, gameIDs); err != nil {
+			return errors.New("error storing session games").Wrap(err)
+		}
+		if err := indexBoardGame(st, Game.Board, Game.ID); err != nil {
+			return err
+		}
+		return storeGameScores(st, Game, Scores)
+	})
+	return Game, err
 }
 
-func newGame(Board int, Session int, Scores map[int]float32) (game, error) {
-	ID, err := incGameCount()
+// indexBoardGame adds a game to the list of games of its board.
+func indexBoardGame(st Store, Board int, Game int) error {
+	gameIDs, err := st.BoardGames(Board)
 	if err != nil {
-		return game{}, err
+		return errors.New("error fetching board games").Wrap(err)
+	}
+	for _, other := range gameIDs {
+		if other == Game {
+			return nil
+		}
 	}
-	Game := game{
-		ID: ID,
-		Board: Board,
-		Session: Session,
+	if err := st.SetBoardGames(Board, append(gameIDs, Game)); err != nil {
+		return errors.New("error storing board games").Wrap(err)
 	}
-	err = Game.store()
+	return nil
+}
+
+// unindexBoardGame takes a game off the list of games of a board.
+func unindexBoardGame(st Store, Board int, Game int) error {
+	gameIDs, err := st.BoardGames(Board)
 	if err != nil {
-		return Game, err
+		return errors.New("error fetching board games").Wrap(err)
 	}
-	gameIDs := make([]int, 0)
-	key := fmt.Sprintf("session-%v-games", Session)
-	if err := app.LocalStorage.Get(key, &gameIDs); err != nil {
-		return Game, errors.New("error fetching session games").Wrap(err)
+	kept := make([]int, 0, len(gameIDs))
+	for _, other := range gameIDs {
+		if other != Game {
+			kept = append(kept, other)
+		}
 	}
-	gameIDs = append(gameIDs, Game.ID)
-	if err := app.LocalStorage.Set(key, gameIDs); err != nil {
-		return Game, errors.New("error storing session games").Wrap(err)
+	if err := st.SetBoardGames(Board, kept); err != nil {
+		return errors.New("error storing board games").Wrap(err)
 	}
+	return nil
+}
 
-	key = fmt.Sprintf("game-%v-scores", Game.ID)
-	if err := app.LocalStorage.Set(key, Scores); err != nil {
-		return Game, errors.New("error storing game scores").Wrap(err)
+// retrieveGamesOfBoard reads the games recorded under a board, through the
+// board list of games.
+func retrieveGamesOfBoard(st Store, ID int) ([]game, error) {
+	GameIDs, err := st.BoardGames(ID)
+	if err != nil {
+		return nil, errors.New("error fetching board games").Wrap(err)
 	}
-	
+	Games := make([]game, len(GameIDs))
+	for idx, id := range GameIDs {
+		if Games[idx], err = retrieveGame(st, id); err != nil {
+			return Games, err
+		}
+	}
+	return Games, nil
+}
+
+// storeGameScores keeps a score for every player in the game, 0 when none
+// was entered. Team members get the score of their team.
+func storeGameScores(st Store, Game game, Scores map[int]float32) error {
+	PlayerScores := make(map[int]float32, len(Game.Players))
+	for _, Player := range Game.Players {
+		PlayerScores[Player] = Scores[Player]
+		if t := stats.TeamOf(Game, Player); t >= 0 {
+			PlayerScores[Player] = Game.Teams[t].Score
+		}
+	}
+	if err := st.SetGameScores(Game.ID, PlayerScores); err != nil {
+		return errors.New("error storing game scores").Wrap(err)
+	}
+	return nil
+}
+
+// updateGame replaces the board, players and scores of a recorded game. A
+// game with PositionalScores keeps its scores, and the flag, until it is
+// given its players.
+func updateGame(st Store, Game game, Scores map[int]float32) (game, error) {
+	Game = copyGame(Game)
+	if len(Game.Players) > 0 {
+		Game.PositionalScores = false
+	}
+	err := st.Batch(func(st Store) error {
+		Previous, err := retrieveGame(st, Game.ID)
+		if err != nil {
+			return err
+		}
+		if Previous.Board != Game.Board {
+			if err := unindexBoardGame(st, Previous.Board, Game.ID); err != nil {
+				return err
+			}
+			if err := indexBoardGame(st, Game.Board, Game.ID); err != nil {
+				return err
+			}
+		}
+		if err := storeGame(st, Game); err != nil {
+			return err
+		}
+		if Game.PositionalScores {
+			return nil
+		}
+		return storeGameScores(st, Game, Scores)
+	})
 	return Game, err
 }
 
-func (g game) store() error {
-	if err := app.LocalStorage.Set(fmt.Sprintf("game-%v", g.ID), g); err != nil {
+// deleteGame removes the game from its session, then deletes it along with
+// its scores.
+func deleteGame(st Store, ID int) error {
+	Game, err := retrieveGame(st, ID)
+	if err != nil {
+		return err
+	}
+	return st.Batch(func(st Store) error {
+		gameIDs, err := st.SessionGames(Game.Session)
+		if err != nil {
+			return errors.New("error fetching session games").Wrap(err)
+		}
+		kept := make([]int, 0, len(gameIDs))
+		for _, other := range gameIDs {
+			if other != ID {
+				kept = append(kept, other)
+			}
+		}
+		if err := st.SetSessionGames(Game.Session, kept); err != nil {
+			return errors.New("error storing session games").Wrap(err)
+		}
+		if err := unindexBoardGame(st, Game.Board, ID); err != nil {
+			return err
+		}
+		if err := st.DelGame(ID); err != nil {
+			return errors.Newf("error deleting game %v", ID).Wrap(err)
+		}
+		return nil
+	})
+}
+
+func storeGame(st Store, g game) error {
+	if err := st.SetGame(g); err != nil {
 		return errors.New("error storing game").Wrap(err)
 	}
 	return nil
 }
 
+// mergePreview lists what merging the absorbed record into the kept one
+// touches: the games referencing the absorbed one, and among them the games
+// referencing both, which cannot be merged. Kind is "player" or "board".
+func mergePreview(st Store, kind string, Kept int, Absorbed int) (affected []game, conflicts []game, err error) {
+	Games, err := retrieveAllGames(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, Game := range Games {
+		if kind == "board" {
+			if Game.Board == Absorbed || hasExpansion(Game, Absorbed) {
+				affected = append(affected, Game)
+			}
+			continue
+		}
+		if !stats.HasPlayer(Game, Absorbed) {
+			continue
+		}
+		affected = append(affected, Game)
+		if stats.HasPlayer(Game, Kept) {
+			conflicts = append(conflicts, Game)
+		}
+	}
+	return affected, conflicts, nil
+}
+
+// mergePlayers rewrites every game of the absorbed player, and its scores, to
+// the kept player and then deletes the absorbed player. Sessions the absorbed
+// player attended are attended by the kept one instead.
+func mergePlayers(st Store, Kept int, Absorbed int) error {
+	if Kept == Absorbed {
+		return errors.Newf("cannot merge player %v into itself", Kept)
+	}
+	affected, conflicts, err := mergePreview(st, "player", Kept, Absorbed)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return errors.Newf("players %v and %v played together in %v games", Kept, Absorbed, len(conflicts))
+	}
+	Sessions, err := retrieveAllSessions(st)
+	if err != nil {
+		return err
+	}
+	return st.Batch(func(st Store) error {
+		for _, Session := range Sessions {
+			if !stats.HasAttendee(Session, Absorbed) {
+				continue
+			}
+			Attendees := make([]int, 0, len(Session.Attendees))
+			for _, Player := range Session.Attendees {
+				if Player == Absorbed {
+					Player = Kept
+				}
+				if Player != Kept || !stats.HasAttendee(session{Attendees: Attendees}, Kept) {
+					Attendees = append(Attendees, Player)
+				}
+			}
+			Session.Attendees = Attendees
+			if err := storeSession(st, Session); err != nil {
+				return err
+			}
+		}
+		for _, Game := range affected {
+			for pos, Player := range Game.Players {
+				if Player == Absorbed {
+					Game.Players[pos] = Kept
+				}
+			}
+			if Sheet, ok := Game.Breakdown[Absorbed]; ok {
+				delete(Game.Breakdown, Absorbed)
+				Game.Breakdown[Kept] = Sheet
+			}
+			if Faction, ok := Game.Factions[Absorbed]; ok {
+				delete(Game.Factions, Absorbed)
+				Game.Factions[Kept] = Faction
+			}
+			if t := stats.TeamOf(Game, Absorbed); t >= 0 {
+				for pos, Member := range Game.Teams[t].Players {
+					if Member == Absorbed {
+						Game.Teams[t].Players[pos] = Kept
+					}
+				}
+			}
+			if err := storeGame(st, Game); err != nil {
+				return err
+			}
+			if Game.PositionalScores {
+				continue
+			}
+			Scores, err := st.GameScores(Game.ID)
+			if err != nil {
+				return errors.New("error fetching game scores").Wrap(err)
+			}
+			if Score, ok := Scores[Absorbed]; ok {
+				delete(Scores, Absorbed)
+				Scores[Kept] = Score
+			}
+			if err := st.SetGameScores(Game.ID, Scores); err != nil {
+				return errors.New("error storing game scores").Wrap(err)
+			}
+		}
+		if err := st.DelPlayer(Absorbed); err != nil {
+			return errors.Newf("error deleting player %v", Absorbed).Wrap(err)
+		}
+		return nil
+	})
+}
+
+// mergeBoards moves every game of the absorbed board to the kept board and
+// then deletes the absorbed board. Its expansions and variants, and the games
+// played with it as an expansion, are moved to the kept board as well.
+func mergeBoards(st Store, Kept int, Absorbed int) error {
+	if Kept == Absorbed {
+		return errors.Newf("cannot merge board %v into itself", Kept)
+	}
+	affected, _, err := mergePreview(st, "board", Kept, Absorbed)
+	if err != nil {
+		return err
+	}
+	Boards, err := retrieveAllBoards(st)
+	if err != nil {
+		return err
+	}
+	return st.Batch(func(st Store) error {
+		for _, Game := range affected {
+			if Game.Board == Absorbed {
+				Game.Board = Kept
+				if err := indexBoardGame(st, Kept, Game.ID); err != nil {
+					return err
+				}
+			}
+			Expansions := make([]int, 0, len(Game.Expansions))
+			for _, Expansion := range Game.Expansions {
+				if Expansion == Absorbed {
+					Expansion = Kept
+				}
+				if Expansion != Game.Board && !hasExpansion(game{Expansions: Expansions}, Expansion) {
+					Expansions = append(Expansions, Expansion)
+				}
+			}
+			Game.Expansions = Expansions
+			if err := storeGame(st, Game); err != nil {
+				return err
+			}
+		}
+		for _, Addon := range addonsOf(Boards, Absorbed) {
+			Addon.Base = Kept
+			Addon.HasBase = Addon.ID != Kept
+			if err := storeBoard(st, Addon); err != nil {
+				return err
+			}
+		}
+		if err := st.DelBoard(Absorbed); err != nil {
+			return errors.Newf("error deleting board %v", Absorbed).Wrap(err)
+		}
+		return nil
+	})
+}
+
+// sheetTotal adds up the categories of a score sheet.
+func sheetTotal(Sheet map[string]float32, Categories []string) float32 {
+	var Total float32
+	for _, Category := range Categories {
+		Total += Sheet[Category]
+	}
+	return Total
+}
+
+// parseNames reads a comma separated list of names, such as score sheet
+// categories or factions, dropping blank and repeated ones.
+func parseNames(input string) []string {
+	Names := make([]string, 0)
+	seen := map[string]bool{}
+	for _, Name := range strings.Split(input, ",") {
+		Name = strings.TrimSpace(Name)
+		if len(Name) == 0 || seen[normalizeName(Name)] {
+			continue
+		}
+		seen[normalizeName(Name)] = true
+		Names = append(Names, Name)
+	}
+	return Names
+}
+
+// noteMatch is a session or game whose notes matched a search.
+type noteMatch struct {
+	Session session
+	// -1 for the notes of the session itself
+	Game int
+	Board int
+	Notes string
+}
+
+// searchNotes finds the sessions and games whose notes contain the query,
+// ignoring case, accents and spacing, most recent session first.
+func searchNotes(st Store, query string) ([]noteMatch, error) {
+	Matches := make([]noteMatch, 0)
+	query = normalizeName(query)
+	if len(query) == 0 {
+		return Matches, nil
+	}
+	Sessions, err := retrieveAllSessions(st)
+	if err != nil {
+		return Matches, err
+	}
+	sort.SliceStable(Sessions, func(i, j int) bool {
+		return Sessions[i].Date > Sessions[j].Date
+	})
+	for _, Session := range Sessions {
+		if strings.Contains(normalizeName(Session.Notes), query) {
+			Matches = append(Matches, noteMatch{Session: Session, Game: -1, Notes: Session.Notes})
+		}
+		Games, err := retrieveGamesInSession(st, Session.ID)
+		if err != nil {
+			return Matches, err
+		}
+		for _, Game := range Games {
+			if strings.Contains(normalizeName(Game.Notes), query) {
+				Matches = append(Matches, noteMatch{Session: Session, Game: Game.ID, Board: Game.Board, Notes: Game.Notes})
+			}
+		}
+	}
+	return Matches, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdatePositionalGame(t *testing.T) {
+	st := newMemoryStore()
+	Session, _ := newSession(st)
+	Board, _ := newBoard(st, "Catan")
+	Other, _ := newBoard(st, "Azul")
+	Game, err := newGame(st, game{Board: Board.ID, Session: Session.ID}, map[int]float32{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Game.PositionalScores = true
+	st.SetGame(Game)
+	st.SetGameScores(Game.ID, map[int]float32{0: 12, 1: 9})
+
+	// saved without players: the scores and the flag stay
+	Game.Board = Other.ID
+	if _, err := updateGame(st, Game, map[int]float32{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := st.Game(Game.ID); !got.PositionalScores || got.Board != Other.ID {
+		t.Errorf("game = %+v; want it flagged, on the new board", got)
+	}
+	if got, _ := st.GameScores(Game.ID); !reflect.DeepEqual(got, map[int]float32{0: 12, 1: 9}) {
+		t.Errorf("scores = %v; want them kept by position", got)
+	}
+
+	// with the players picked, the scores are by player ID
+	Game.Players = []int{4, 2}
+	if _, err := updateGame(st, Game, map[int]float32{4: 12, 2: 9}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := st.Game(Game.ID); got.PositionalScores {
+		t.Error("game still flagged once its players were picked")
+	}
+	if got, _ := st.GameScores(Game.ID); !reflect.DeepEqual(got, map[int]float32{4: 12, 2: 9}) {
+		t.Errorf("scores = %v; want them by player", got)
+	}
+}
@@ -0,0 +1,103 @@
+// Package stats holds the logbook records and the statistics computed over
+// them. It is plain Go over memory: the app loads a Logbook from its storage,
+// and the tests build one by hand.
+package stats
+
+type Player struct {
+	ID int
+	Text string
+	Hidden bool
+}
+
+type Board struct {
+	ID int
+	Text string
+	Hidden bool
+	Scoring ScoringMode
+	// named parts of the score sheet, added up into the score
+	Categories []string
+	// expansions and variants are played with the board Base; games are
+	// recorded under the base board, listing them in Expansions
+	HasBase bool
+	Base int
+	Variant bool
+	// factions, roles or colors players can pick from
+	Factions []string
+}
+
+type Game struct {
+	ID int
+	Board int
+	Session int
+	// players in the order they were entered, or in turn order, first
+	// player first, if SeatOrder
+	Players []int
+	SeatOrder bool
+	// set on games recorded before scores were keyed by player ID; their
+	// scores are keyed by position in a player list that was not kept
+	PositionalScores bool
+	// for boards with Cooperative scoring, whether the group won
+	Won bool
+	// empty unless played in teams; players in no team play on their own
+	Teams []Team
+	// the score sheet of the board when the game was recorded, and each
+	// player score by category; the scores are the totals
+	Categories []string
+	Breakdown map[int]map[string]float32
+	// unix times the game started and ended, 0 when not known
+	Start int64
+	End int64
+	Notes string
+	// expansions and variants of the board that were played, by board ID
+	Expansions []int
+	// faction, role or color each player played, when the board has some
+	Factions map[int]string
+}
+
+// Team is a group of players sharing a score. Every member gets the team
+// score in the game scores, so statistics credit all of them.
+type Team struct {
+	Name string
+	Players []int
+	Score float32
+}
+
+// TeamOf is the position in Game.Teams of the team the player is in, or -1.
+func TeamOf(Game Game, Player int) int {
+	for idx, Team := range Game.Teams {
+		for _, Member := range Team.Players {
+			if Member == Player {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+func HasPlayer(Game Game, Player int) bool {
+	return hasID(Game.Players, Player)
+}
+
+type Session struct {
+	ID int
+	Date int64
+	Notes string
+	// where it was played, if HasLocation
+	HasLocation bool
+	Location int
+	// players of the evening, each new game starts with them
+	Attendees []int
+}
+
+func HasAttendee(Session Session, Player int) bool {
+	return hasID(Session.Attendees, Player)
+}
+
+func hasID(IDs []int, ID int) bool {
+	for _, other := range IDs {
+		if other == ID {
+			return true
+		}
+	}
+	return false
+}
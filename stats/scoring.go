@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"sort"
+)
+
+// ScoringMode tells how the scores of a board decide who won.
+type ScoringMode int
+
+const (
+	HighestWins ScoringMode = iota
+	LowestWins
+	// the players win or lose together, see Game.Won
+	Cooperative
+	// the score is the finishing place, 1 for first
+	RankOnly
+)
+
+var ScoringModes = []ScoringMode{HighestWins, LowestWins, Cooperative, RankOnly}
+
+func (m ScoringMode) String() string {
+	switch m {
+	case LowestWins:
+		return "lowest score wins"
+	case Cooperative:
+		return "cooperative"
+	case RankOnly:
+		return "finishing order"
+	default:
+		return "highest score wins"
+	}
+}
+
+// Placement is where a player finished in a game, 1 for first. Tied players
+// share a place and the next place is skipped (1, 1, 3). Team members share
+// the place of their team.
+type Placement struct {
+	Player int
+	Score float32
+	Place int
+	// empty when not playing in a team
+	Team string
+}
+
+// Placements orders the players of a game from first to last according to
+// the scoring mode of its board. Teams are ranked as one, by the team score.
+// In cooperative games everybody shares first place if the group won and
+// last place otherwise.
+func Placements(Mode ScoringMode, Game Game, Scores map[int]float32) []Placement {
+	// the ranked units: each team, and each player in no team
+	type unit struct {
+		Players []int
+		Score float32
+		Team string
+	}
+	Units := make([]unit, 0, len(Scores))
+	for _, Team := range Game.Teams {
+		if len(Team.Players) == 0 {
+			continue
+		}
+		Units = append(Units, unit{Players: Team.Players, Score: Team.Score, Team: Team.Name})
+	}
+	for Player, Score := range Scores {
+		if TeamOf(Game, Player) < 0 {
+			Units = append(Units, unit{Players: []int{Player}, Score: Score})
+		}
+	}
+	first := func(u unit) int {
+		if len(u.Players) == 0 {
+			return -1
+		}
+		return u.Players[0]
+	}
+
+	better := func(a, b float32) bool { return a > b }
+	if Mode == LowestWins || Mode == RankOnly {
+		better = func(a, b float32) bool { return a < b }
+	}
+	if Mode == Cooperative {
+		better = func(a, b float32) bool { return false }
+	}
+	sort.SliceStable(Units, func(i, j int) bool {
+		if better(Units[i].Score, Units[j].Score) {
+			return true
+		}
+		if better(Units[j].Score, Units[i].Score) {
+			return false
+		}
+		return first(Units[i]) < first(Units[j])
+	})
+
+	Places := make([]Placement, 0, len(Scores))
+	Place := 0
+	for idx, Unit := range Units {
+		switch {
+		case Mode == Cooperative && Game.Won:
+			Place = 1
+		case Mode == Cooperative:
+			Place = len(Units)
+		case idx == 0 || Unit.Score != Units[idx-1].Score:
+			Place = idx + 1
+		}
+		for _, Player := range Unit.Players {
+			Places = append(Places, Placement{
+				Player: Player,
+				Score: Unit.Score,
+				Place: Place,
+				Team: Unit.Team,
+			})
+		}
+	}
+	return Places
+}
+
+// Winners are the players in first place. A lost cooperative game has none.
+func Winners(Mode ScoringMode, Game Game, Scores map[int]float32) []int {
+	Winners := make([]int, 0)
+	if Mode == Cooperative && !Game.Won {
+		return Winners
+	}
+	for _, Place := range Placements(Mode, Game, Scores) {
+		if Place.Place == 1 {
+			Winners = append(Winners, Place.Player)
+		}
+	}
+	return Winners
+}
@@ -0,0 +1,490 @@
+package stats
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GameDuration is how long a game lasted, 0 when its times are not known.
+func GameDuration(Game Game) time.Duration {
+	if Game.Start == 0 || Game.End <= Game.Start {
+		return 0
+	}
+	return time.Duration(Game.End - Game.Start) * time.Second
+}
+
+// TimePlayed is the total time each player spent playing, by player ID.
+// Games without times are left out.
+func TimePlayed(Games []Game) map[int]time.Duration {
+	byPlayer := map[int]time.Duration{}
+	for _, Game := range Games {
+		d := GameDuration(Game)
+		for _, Player := range Game.Players {
+			byPlayer[Player] += d
+		}
+	}
+	return byPlayer
+}
+
+// ComboOf is the board of a game followed by the expansions and variants
+// played with it, sorted, so games played with the same ones share a combo.
+func ComboOf(Game Game) []int {
+	Expansions := append([]int{}, Game.Expansions...)
+	sort.Ints(Expansions)
+	return append([]int{Game.Board}, Expansions...)
+}
+
+// ComboKey identifies a combo, as in "3+7+9".
+func ComboKey(Combo []int) string {
+	ids := make([]string, len(Combo))
+	for idx, ID := range Combo {
+		ids[idx] = strconv.Itoa(ID)
+	}
+	return strings.Join(ids, "+")
+}
+
+// BoardPlays is how often a board, or a combo of a board with expansions,
+// was played and for how long on average.
+type BoardPlays struct {
+	Combo []int
+	Plays int
+	Average time.Duration
+}
+
+// PlaysByBoard rolls the games up to their base board, by board ID.
+func PlaysByBoard(Games []Game) map[int]BoardPlays {
+	byBoard := map[int]BoardPlays{}
+	for _, Plays := range groupPlays(Games, func(Game Game) []int { return []int{Game.Board} }) {
+		byBoard[Plays.Combo[0]] = Plays
+	}
+	return byBoard
+}
+
+// PlaysByCombo breaks the games of each board down by the expansions and
+// variants played, by ComboKey.
+func PlaysByCombo(Games []Game) map[string]BoardPlays {
+	return groupPlays(Games, ComboOf)
+}
+
+func groupPlays(Games []Game, comboFn func(Game) []int) map[string]BoardPlays {
+	Groups := map[string]BoardPlays{}
+	timed := map[string]int{}
+	for _, Game := range Games {
+		Combo := comboFn(Game)
+		key := ComboKey(Combo)
+		Plays := Groups[key]
+		Plays.Combo = Combo
+		Plays.Plays++
+		if d := GameDuration(Game); d > 0 {
+			Plays.Average += d
+			timed[key]++
+		}
+		Groups[key] = Plays
+	}
+	for key, Plays := range Groups {
+		if timed[key] > 0 {
+			Plays.Average /= time.Duration(timed[key])
+			Groups[key] = Plays
+		}
+	}
+	return Groups
+}
+
+// FactionRecord is how often a faction of a board was played and won.
+type FactionRecord struct {
+	Faction string
+	Plays int
+	Wins int
+}
+
+// FactionStats counts the plays and wins of each faction in the games of a
+// board, in the order of the board factions. Factions that are no longer
+// listed but were played come last. Scores are by game ID.
+func FactionStats(Board Board, Games []Game, Scores map[int]map[int]float32) []FactionRecord {
+	Records := make([]FactionRecord, 0, len(Board.Factions))
+	index := map[string]int{}
+	for _, Faction := range Board.Factions {
+		index[Faction] = len(Records)
+		Records = append(Records, FactionRecord{Faction: Faction})
+	}
+	for _, Game := range Games {
+		if Game.Board != Board.ID || len(Game.Factions) == 0 {
+			continue
+		}
+		won := map[int]bool{}
+		for _, Winner := range Winners(Board.Scoring, Game, Scores[Game.ID]) {
+			won[Winner] = true
+		}
+		for _, Player := range Game.Players {
+			Faction, ok := Game.Factions[Player]
+			if !ok {
+				continue
+			}
+			idx, ok := index[Faction]
+			if !ok {
+				idx = len(Records)
+				index[Faction] = idx
+				Records = append(Records, FactionRecord{Faction: Faction})
+			}
+			Records[idx].Plays++
+			if won[Player] {
+				Records[idx].Wins++
+			}
+		}
+	}
+	return Records
+}
+
+// WinRate is the share of plays won, in percent.
+func WinRate(Wins int, Plays int) float64 {
+	if Plays == 0 {
+		return 0
+	}
+	return float64(Wins) * 100 / float64(Plays)
+}
+
+// SeatRecord is how often the player in a seat won, seat 1 going first.
+type SeatRecord struct {
+	Seat int
+	Plays int
+	Wins int
+}
+
+// SeatStats counts the plays and wins by seat in the games of a board that
+// recorded their turn order. Scores are by game ID.
+func SeatStats(Board Board, Games []Game, Scores map[int]map[int]float32) []SeatRecord {
+	Records := make([]SeatRecord, 0)
+	for _, Game := range Games {
+		if Game.Board != Board.ID || !Game.SeatOrder {
+			continue
+		}
+		won := map[int]bool{}
+		for _, Winner := range Winners(Board.Scoring, Game, Scores[Game.ID]) {
+			won[Winner] = true
+		}
+		for pos, Player := range Game.Players {
+			for len(Records) <= pos {
+				Records = append(Records, SeatRecord{Seat: len(Records) + 1})
+			}
+			Records[pos].Plays++
+			if won[Player] {
+				Records[pos].Wins++
+			}
+		}
+	}
+	return Records
+}
+
+// Logbook is everything the statistics need, loaded once by the app so the
+// computations below are plain functions over memory.
+type Logbook struct {
+	Sessions map[int]Session
+	// every game in the order it was played: by session date, then by
+	// position in its session
+	Games []Game
+	// by game ID, then player ID
+	Scores map[int]map[int]float32
+	Boards map[int]Board
+	Players []Player
+}
+
+// Placements of a game, by the scoring mode of its board.
+func (l Logbook) Placements(Game Game) []Placement {
+	return Placements(l.Boards[Game.Board].Scoring, Game, l.Scores[Game.ID])
+}
+
+// Place is where the player finished in the game, 0 if not in it.
+func (l Logbook) Place(Game Game, Player int) int {
+	for _, Place := range l.Placements(Game) {
+		if Place.Player == Player {
+			return Place.Place
+		}
+	}
+	return 0
+}
+
+// Won tells whether the player is among the winners of the game.
+func (l Logbook) Won(Game Game, Player int) bool {
+	for _, Winner := range Winners(l.Boards[Game.Board].Scoring, Game, l.Scores[Game.ID]) {
+		if Winner == Player {
+			return true
+		}
+	}
+	return false
+}
+
+// BoardCount is how many times a board was played.
+type BoardCount struct {
+	Board int
+	Plays int
+}
+
+// PlayerStats is the record of a player over the whole logbook. Games with
+// positional scores cannot be attributed to players and are left out.
+type PlayerStats struct {
+	Player int
+	Games int
+	// sessions with a game of the player, or that the player attended
+	Sessions int
+	Wins int
+	// in percent
+	WinRate float64
+	// average finishing place, over the games that are not cooperative
+	AveragePlace float64
+	// boards by number of plays, most played first
+	Favourites []BoardCount
+	// date of the last session the player played in, 0 if none
+	LastPlayed int64
+}
+
+func ComputePlayerStats(l Logbook, Player int) PlayerStats {
+	Stats := PlayerStats{Player: Player}
+	sessions := map[int]bool{}
+	for ID, Session := range l.Sessions {
+		if HasAttendee(Session, Player) {
+			sessions[ID] = true
+		}
+	}
+	plays := map[int]int{}
+	placed, places := 0, 0
+	for _, Game := range l.Games {
+		if !HasPlayer(Game, Player) {
+			continue
+		}
+		Stats.Games++
+		sessions[Game.Session] = true
+		plays[Game.Board]++
+		if Date := l.Sessions[Game.Session].Date; Date > Stats.LastPlayed {
+			Stats.LastPlayed = Date
+		}
+		if l.Won(Game, Player) {
+			Stats.Wins++
+		}
+		if l.Boards[Game.Board].Scoring != Cooperative {
+			if Place := l.Place(Game, Player); Place > 0 {
+				placed++
+				places += Place
+			}
+		}
+	}
+	Stats.Sessions = len(sessions)
+	Stats.WinRate = WinRate(Stats.Wins, Stats.Games)
+	if placed > 0 {
+		Stats.AveragePlace = float64(places) / float64(placed)
+	}
+	Stats.Favourites = make([]BoardCount, 0, len(plays))
+	for Board, Plays := range plays {
+		Stats.Favourites = append(Stats.Favourites, BoardCount{Board: Board, Plays: Plays})
+	}
+	sort.SliceStable(Stats.Favourites, func(i, j int) bool {
+		if Stats.Favourites[i].Plays != Stats.Favourites[j].Plays {
+			return Stats.Favourites[i].Plays > Stats.Favourites[j].Plays
+		}
+		return Stats.Favourites[i].Board < Stats.Favourites[j].Board
+	})
+	return Stats
+}
+
+// BoardStats is the record of a board over the games of a logbook. Games with
+// positional scores count as plays, but not for the scores nor the wins.
+type BoardStats struct {
+	Board int
+	Plays int
+	// only for boards scored by points, over every player score
+	HasScores bool
+	HighScore float32
+	LowScore float32
+	AverageScore float32
+	// the best score ever, who made it and in which game
+	Record float32
+	RecordHolders []int
+	RecordGame int
+	// who won the board most often, and how often
+	TopWinners []int
+	TopWins int
+	// date of the last session the board was played in
+	LastPlayed int64
+}
+
+func ComputeBoardStats(l Logbook, Board Board) BoardStats {
+	Stats := BoardStats{Board: Board.ID}
+	scored := Board.Scoring == HighestWins || Board.Scoring == LowestWins
+	var total float32
+	scores := 0
+	wins := map[int]int{}
+	for _, Game := range l.Games {
+		if Game.Board != Board.ID {
+			continue
+		}
+		Stats.Plays++
+		if Date := l.Sessions[Game.Session].Date; Date > Stats.LastPlayed {
+			Stats.LastPlayed = Date
+		}
+		if Game.PositionalScores {
+			// played, but its scores are not keyed by player
+			continue
+		}
+		for _, Winner := range Winners(Board.Scoring, Game, l.Scores[Game.ID]) {
+			wins[Winner]++
+		}
+		if !scored {
+			continue
+		}
+		for _, Player := range Game.Players {
+			Score, ok := l.Scores[Game.ID][Player]
+			if !ok {
+				continue
+			}
+			if scores == 0 || Score > Stats.HighScore {
+				Stats.HighScore = Score
+			}
+			if scores == 0 || Score < Stats.LowScore {
+				Stats.LowScore = Score
+			}
+			total += Score
+			scores++
+			better := Score > Stats.Record
+			if Board.Scoring == LowestWins {
+				better = Score < Stats.Record
+			}
+			switch {
+			case len(Stats.RecordHolders) == 0 || better:
+				Stats.Record = Score
+				Stats.RecordHolders = []int{Player}
+				Stats.RecordGame = Game.ID
+			case Score == Stats.Record && !hasID(Stats.RecordHolders, Player):
+				Stats.RecordHolders = append(Stats.RecordHolders, Player)
+			}
+		}
+	}
+	if scores > 0 {
+		Stats.HasScores = true
+		Stats.AverageScore = total / float32(scores)
+	}
+	for Player, Wins := range wins {
+		if Wins > Stats.TopWins {
+			Stats.TopWins = Wins
+			Stats.TopWinners = nil
+		}
+		if Wins == Stats.TopWins {
+			Stats.TopWinners = append(Stats.TopWinners, Player)
+		}
+	}
+	sort.Ints(Stats.TopWinners)
+	return Stats
+}
+
+// RivalryBoard is the head to head record of two players on one board.
+type RivalryBoard struct {
+	Board int
+	Games int
+	AheadA int
+	AheadB int
+	Ties int
+}
+
+// HeadToHead compares two players over the games they played together.
+// Games in the same team, or cooperative games, are counted in Together and
+// have nobody ahead.
+type HeadToHead struct {
+	PlayerA int
+	PlayerB int
+	// the games with both players, in the order they were played
+	Games []int
+	// games where A finished ahead of B, and the other way round
+	AheadA int
+	AheadB int
+	Ties int
+	Together int
+	// by number of games together, most played first
+	Boards []RivalryBoard
+	// longest run of games in a row one of them finished ahead, broken by a
+	// tie or by the other finishing ahead; StreakPlayer is -1 without one
+	StreakPlayer int
+	Streak int
+}
+
+func ComputeHeadToHead(l Logbook, A int, B int) HeadToHead {
+	H := HeadToHead{PlayerA: A, PlayerB: B, Games: make([]int, 0), StreakPlayer: -1}
+	boards := map[int]RivalryBoard{}
+	streakPlayer, streak := -1, 0
+	for _, Game := range l.Games {
+		if A == B || !HasPlayer(Game, A) || !HasPlayer(Game, B) {
+			continue
+		}
+		H.Games = append(H.Games, Game.ID)
+		Board := boards[Game.Board]
+		Board.Board = Game.Board
+		Board.Games++
+		boards[Game.Board] = Board
+
+		var PlaceA, PlaceB Placement
+		for _, Place := range l.Placements(Game) {
+			if Place.Player == A {
+				PlaceA = Place
+			} else if Place.Player == B {
+				PlaceB = Place
+			}
+		}
+		if l.Boards[Game.Board].Scoring == Cooperative || (len(PlaceA.Team) > 0 && PlaceA.Team == PlaceB.Team) {
+			H.Together++
+			continue
+		}
+		ahead := -1
+		switch {
+		case PlaceA.Place < PlaceB.Place:
+			H.AheadA++
+			Board.AheadA++
+			ahead = A
+		case PlaceA.Place > PlaceB.Place:
+			H.AheadB++
+			Board.AheadB++
+			ahead = B
+		default:
+			H.Ties++
+			Board.Ties++
+		}
+		boards[Game.Board] = Board
+
+		if ahead >= 0 && ahead == streakPlayer {
+			streak++
+		} else {
+			streakPlayer, streak = ahead, 1
+		}
+		if ahead >= 0 && streak > H.Streak {
+			H.StreakPlayer, H.Streak = ahead, streak
+		}
+	}
+	H.Boards = make([]RivalryBoard, 0, len(boards))
+	for _, Board := range boards {
+		H.Boards = append(H.Boards, Board)
+	}
+	sort.SliceStable(H.Boards, func(i, j int) bool {
+		if H.Boards[i].Games != H.Boards[j].Games {
+			return H.Boards[i].Games > H.Boards[j].Games
+		}
+		return H.Boards[i].Board < H.Boards[j].Board
+	})
+	return H
+}
+
+// AllHeadToHeads compares every two players who played together, lower
+// player ID first.
+func AllHeadToHeads(l Logbook) []HeadToHead {
+	IDs := make([]int, len(l.Players))
+	for idx, Player := range l.Players {
+		IDs[idx] = Player.ID
+	}
+	sort.Ints(IDs)
+	Rivalries := make([]HeadToHead, 0)
+	for i, A := range IDs {
+		for _, B := range IDs[i + 1:] {
+			if H := ComputeHeadToHead(l, A, B); len(H.Games) > 0 {
+				Rivalries = append(Rivalries, H)
+			}
+		}
+	}
+	return Rivalries
+}
@@ -0,0 +1,203 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+const (
+	Ann = iota
+	Bob
+	Cid
+	Dee
+)
+
+const (
+	Catan = iota
+	Golf
+	Codenames
+)
+
+// testLogbook is a small logbook: two sessions with ties, a lowest score wins
+// board, a team game, and a game from before scores were keyed by player.
+func testLogbook() Logbook {
+	return Logbook{
+		Sessions: map[int]Session{
+			0: {ID: 0, Date: 1600000000},
+			1: {ID: 1, Date: 1600100000},
+		},
+		Games: []Game{
+			// Ann 1st, Bob and Cid share 2nd
+			{ID: 0, Board: Catan, Session: 0, Players: []int{Ann, Bob, Cid}},
+			// Bob and Cid share 1st, Ann 3rd
+			{ID: 1, Board: Catan, Session: 0, Players: []int{Ann, Bob, Cid}},
+			// lowest wins: Ann 1st, Dee 2nd
+			{ID: 2, Board: Golf, Session: 1, Players: []int{Ann, Dee}},
+			// Ann and Bob win as a team
+			{ID: 3, Board: Codenames, Session: 1, Players: []int{Ann, Bob, Cid, Dee}, Teams: []Team{
+				{Name: "Red", Players: []int{Ann, Bob}, Score: 1},
+				{Name: "Blue", Players: []int{Cid, Dee}, Score: 0},
+			}},
+			// scores keyed by position: 0 and 1 are not Ann and Bob
+			{ID: 4, Board: Catan, Session: 1, PositionalScores: true},
+		},
+		Scores: map[int]map[int]float32{
+			0: {Ann: 10, Bob: 8, Cid: 8},
+			1: {Ann: 5, Bob: 9, Cid: 9},
+			2: {Ann: 70, Dee: 80},
+			// team members get the score of their team
+			3: {Ann: 1, Bob: 1, Cid: 0, Dee: 0},
+			4: {0: 100, 1: 50},
+		},
+		Boards: map[int]Board{
+			Catan: {ID: Catan, Text: "Catan", Scoring: HighestWins},
+			Golf: {ID: Golf, Text: "Golf", Scoring: LowestWins},
+			Codenames: {ID: Codenames, Text: "Codenames", Scoring: HighestWins},
+		},
+		Players: []Player{{ID: Ann, Text: "Ann"}, {ID: Bob, Text: "Bob"}, {ID: Cid, Text: "Cid"}, {ID: Dee, Text: "Dee"}},
+	}
+}
+
+func TestPlacements(t *testing.T) {
+	Game := Game{Players: []int{Ann, Bob, Cid}}
+	Scores := map[int]float32{Ann: 3, Bob: 1, Cid: 3}
+	tests := []struct {
+		Mode ScoringMode
+		Won bool
+		want map[int]int
+	}{
+		{HighestWins, false, map[int]int{Ann: 1, Cid: 1, Bob: 3}},
+		{LowestWins, false, map[int]int{Bob: 1, Ann: 2, Cid: 2}},
+		{RankOnly, false, map[int]int{Bob: 1, Ann: 2, Cid: 2}},
+		{Cooperative, true, map[int]int{Ann: 1, Bob: 1, Cid: 1}},
+		{Cooperative, false, map[int]int{Ann: 3, Bob: 3, Cid: 3}},
+	}
+	for _, test := range tests {
+		Game.Won = test.Won
+		got := map[int]int{}
+		for _, Place := range Placements(test.Mode, Game, Scores) {
+			got[Place.Player] = Place.Place
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v, won %v: places %v; want %v", test.Mode, test.Won, got, test.want)
+		}
+	}
+	if Winners := Winners(Cooperative, Game, Scores); len(Winners) != 0 {
+		t.Errorf("winners of a lost cooperative game = %v; want none", Winners)
+	}
+}
+
+func TestPlayerStats(t *testing.T) {
+	Log := testLogbook()
+	tests := []struct {
+		Player int
+		Games, Sessions, Wins int
+		WinRate, AveragePlace float64
+	}{
+		{Ann, 4, 2, 3, 75, (1 + 3 + 1 + 1) / 4.0},
+		{Bob, 3, 2, 2, 200 / 3.0, (2 + 1 + 1) / 3.0},
+		{Cid, 3, 2, 1, 100 / 3.0, (2 + 1 + 2) / 3.0},
+		{Dee, 2, 1, 0, 0, 2},
+	}
+	for _, test := range tests {
+		Stats := ComputePlayerStats(Log, test.Player)
+		if Stats.Games != test.Games || Stats.Sessions != test.Sessions || Stats.Wins != test.Wins {
+			t.Errorf("player %v: games %v, sessions %v, wins %v; want %v, %v, %v", test.Player,
+				Stats.Games, Stats.Sessions, Stats.Wins, test.Games, test.Sessions, test.Wins)
+		}
+		if math.Abs(Stats.WinRate - test.WinRate) > 1e-9 {
+			t.Errorf("player %v: win rate %v; want %v", test.Player, Stats.WinRate, test.WinRate)
+		}
+		if math.Abs(Stats.AveragePlace - test.AveragePlace) > 1e-9 {
+			t.Errorf("player %v: average place %v; want %v", test.Player, Stats.AveragePlace, test.AveragePlace)
+		}
+		if Stats.LastPlayed != 1600100000 {
+			t.Errorf("player %v: last played %v; want 1600100000", test.Player, Stats.LastPlayed)
+		}
+	}
+
+	Favourites := ComputePlayerStats(Log, Ann).Favourites
+	want := []BoardCount{{Board: Catan, Plays: 2}, {Board: Golf, Plays: 1}, {Board: Codenames, Plays: 1}}
+	if !reflect.DeepEqual(Favourites, want) {
+		t.Errorf("favourites of Ann = %v; want %v", Favourites, want)
+	}
+
+	// attending a session counts, even without playing
+	Log.Sessions[1] = Session{ID: 1, Date: 1600100000, Attendees: []int{Cid}}
+	Log.Games = Log.Games[:2]
+	if Stats := ComputePlayerStats(Log, Cid); Stats.Sessions != 2 || Stats.Games != 2 {
+		t.Errorf("Cid attending session 1: %v sessions, %v games; want 2 and 2", Stats.Sessions, Stats.Games)
+	}
+}
+
+func TestBoardStats(t *testing.T) {
+	Log := testLogbook()
+	Stats := ComputeBoardStats(Log, Log.Boards[Catan])
+	// the legacy game is a play, but its 100 is nobody's score or win
+	if Stats.Plays != 3 {
+		t.Errorf("plays = %v; want 3", Stats.Plays)
+	}
+	if Stats.HighScore != 10 || Stats.LowScore != 5 {
+		t.Errorf("high %v, low %v; want 10, 5", Stats.HighScore, Stats.LowScore)
+	}
+	if math.Abs(float64(Stats.AverageScore) - 49 / 6.0) > 1e-5 {
+		t.Errorf("average %v; want %v", Stats.AverageScore, 49 / 6.0)
+	}
+	if Stats.Record != 10 || !reflect.DeepEqual(Stats.RecordHolders, []int{Ann}) {
+		t.Errorf("record %v by %v; want 10 by Ann", Stats.Record, Stats.RecordHolders)
+	}
+	if Stats.TopWins != 1 || !reflect.DeepEqual(Stats.TopWinners, []int{Ann, Bob, Cid}) {
+		t.Errorf("top winners %v with %v wins; want Ann, Bob and Cid with 1", Stats.TopWinners, Stats.TopWins)
+	}
+
+	Lowest := ComputeBoardStats(Log, Log.Boards[Golf])
+	if Lowest.Record != 70 || !reflect.DeepEqual(Lowest.TopWinners, []int{Ann}) {
+		t.Errorf("golf record %v, winners %v; want 70 and Ann", Lowest.Record, Lowest.TopWinners)
+	}
+}
+
+func TestHeadToHead(t *testing.T) {
+	Log := testLogbook()
+	H := ComputeHeadToHead(Log, Ann, Bob)
+	if len(H.Games) != 3 || H.AheadA != 1 || H.AheadB != 1 || H.Ties != 0 || H.Together != 1 {
+		t.Errorf("Ann vs Bob = %+v; want 3 games, 1 ahead each and 1 together", H)
+	}
+	if H.StreakPlayer != Ann || H.Streak != 1 {
+		t.Errorf("Ann vs Bob streak %v by %v; want 1 by Ann", H.Streak, H.StreakPlayer)
+	}
+	want := []RivalryBoard{
+		{Board: Catan, Games: 2, AheadA: 1, AheadB: 1},
+		{Board: Codenames, Games: 1},
+	}
+	if !reflect.DeepEqual(H.Boards, want) {
+		t.Errorf("Ann vs Bob boards = %+v; want %+v", H.Boards, want)
+	}
+
+	H = ComputeHeadToHead(Log, Bob, Cid)
+	if H.AheadA != 1 || H.AheadB != 0 || H.Ties != 2 || H.Streak != 1 || H.StreakPlayer != Bob {
+		t.Errorf("Bob vs Cid = %+v; want Bob ahead once after 2 ties", H)
+	}
+
+	if H := ComputeHeadToHead(Log, Ann, Ann); len(H.Games) != 0 {
+		t.Errorf("Ann vs Ann = %+v; want no games", H)
+	}
+	if got := len(AllHeadToHeads(Log)); got != 6 {
+		t.Errorf("head to heads = %v; want every pair of the 4 players", got)
+	}
+}
+
+func TestPlaysByCombo(t *testing.T) {
+	Games := []Game{
+		{Board: Catan, Expansions: []int{7, 5}, Start: 1000, End: 4600},
+		{Board: Catan, Expansions: []int{5, 7}, Start: 1000, End: 8200},
+		{Board: Catan},
+	}
+	Combos := PlaysByCombo(Games)
+	if Plays := Combos["0+5+7"]; Plays.Plays != 2 || Plays.Average.Minutes() != 90 {
+		t.Errorf("Catan with 5 and 7 = %+v; want 2 plays of 90 minutes", Plays)
+	}
+	if Plays := PlaysByBoard(Games)[Catan]; Plays.Plays != 3 || Plays.Average.Minutes() != 90 {
+		t.Errorf("Catan = %+v; want 3 plays of 90 minutes, untimed ones left out", Plays)
+	}
+}
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/maxence-charriere/go-app/v7/pkg/app"
+	"github.com/maxence-charriere/go-app/v7/pkg/errors"
+)
+
+// Store is the backend holding the logbook records. The logic in state.go
+// only talks to a Store, so the same code runs over the browser LocalStorage
+// or over plain Go maps.
+type Store interface {
+	// Count returns the next free ID for the given kind of record
+	// ("session", "game", "player", "board" or "location").
+	Count(kind string) (int, error)
+	SetCount(kind string, count int) error
+
+	Session(ID int) (session, error)
+	SetSession(s session) error
+	SessionGames(ID int) ([]int, error)
+	SetSessionGames(ID int, games []int) error
+	// DelSession deletes the session and its list of games, but not the
+	// games themselves.
+	DelSession(ID int) error
+
+	Game(ID int) (game, error)
+	SetGame(g game) error
+	GameScores(ID int) (map[int]float32, error)
+	SetGameScores(ID int, scores map[int]float32) error
+	// DelGame deletes the game and its scores.
+	DelGame(ID int) error
+
+	Player(ID int) (player, error)
+	SetPlayer(p player) error
+	DelPlayer(ID int) error
+
+	Board(ID int) (board, error)
+	SetBoard(b board) error
+	// BoardGames lists the games recorded under a board, so they can be
+	// found without reading every game.
+	BoardGames(ID int) ([]int, error)
+	SetBoardGames(ID int, games []int) error
+	// DelBoard deletes the board and its list of games, but not the games.
+	DelBoard(ID int) error
+
+	Location(ID int) (location, error)
+	SetLocation(l location) error
+
+	// Exists tells whether a record of the given kind was stored under ID.
+	// IDs below the count may be missing once records get deleted.
+	Exists(kind string, ID int) (bool, error)
+
+	// Clear deletes every logbook record and resets the counts.
+	Clear() error
+
+	// Batch runs fn against a Store whose writes are all kept if fn returns
+	// nil. If fn fails, every record it wrote is restored to its previous
+	// value. Batches nest: a failed inner batch only undoes its own writes,
+	// and the outer batch can go on. Once the outer batch fails, the inner
+	// writes are undone too.
+	Batch(fn func(Store) error) error
+}
+
+// localStore keeps the records in a browser storage, one JSON value per key:
+//
+//	session-count, game-count, player-count, board-count, location-count
+//	session-N, session-N-games
+//	game-N, game-N-scores
+//	player-N
+//	board-N, board-N-games
+//	location-N
+type localStore struct {
+	kv app.BrowserStorage
+}
+
+func newLocalStore(kv app.BrowserStorage) *localStore {
+	return &localStore{kv: kv}
+}
+
+func (l *localStore) get(key string, v interface{}) error {
+	if err := l.kv.Get(key, v); err != nil {
+		return errors.Newf("error fetching %v", key).Wrap(err)
+	}
+	return nil
+}
+
+func (l *localStore) set(key string, v interface{}) error {
+	if err := l.kv.Set(key, v); err != nil {
+		return errors.Newf("error storing %v", key).Wrap(err)
+	}
+	return nil
+}
+
+func (l *localStore) Count(kind string) (int, error) {
+	count := 0
+	return count, l.get(kind+"-count", &count)
+}
+
+func (l *localStore) SetCount(kind string, count int) error {
+	return l.set(kind+"-count", count)
+}
+
+func (l *localStore) Session(ID int) (session, error) {
+	Session := session{}
+	return Session, l.get(fmt.Sprintf("session-%v", ID), &Session)
+}
+
+func (l *localStore) SetSession(s session) error {
+	return l.set(fmt.Sprintf("session-%v", s.ID), s)
+}
+
+func (l *localStore) SessionGames(ID int) ([]int, error) {
+	GameIDs := make([]int, 0)
+	return GameIDs, l.get(fmt.Sprintf("session-%v-games", ID), &GameIDs)
+}
+
+func (l *localStore) SetSessionGames(ID int, games []int) error {
+	return l.set(fmt.Sprintf("session-%v-games", ID), games)
+}
+
+func (l *localStore) DelSession(ID int) error {
+	l.kv.Del(fmt.Sprintf("session-%v", ID))
+	l.kv.Del(fmt.Sprintf("session-%v-games", ID))
+	return nil
+}
+
+func (l *localStore) Game(ID int) (game, error) {
+	Game := game{}
+	return Game, l.get(fmt.Sprintf("game-%v", ID), &Game)
+}
+
+func (l *localStore) SetGame(g game) error {
+	return l.set(fmt.Sprintf("game-%v", g.ID), g)
+}
+
+func (l *localStore) GameScores(ID int) (map[int]float32, error) {
+	ScoreMap := make(map[int]float32)
+	return ScoreMap, l.get(fmt.Sprintf("game-%v-scores", ID), &ScoreMap)
+}
+
+func (l *localStore) SetGameScores(ID int, scores map[int]float32) error {
+	return l.set(fmt.Sprintf("game-%v-scores", ID), scores)
+}
+
+func (l *localStore) DelGame(ID int) error {
+	l.kv.Del(fmt.Sprintf("game-%v", ID))
+	l.kv.Del(fmt.Sprintf("game-%v-scores", ID))
+	return nil
+}
+
+func (l *localStore) Player(ID int) (player, error) {
+	Player := player{}
+	return Player, l.get(fmt.Sprintf("player-%v", ID), &Player)
+}
+
+func (l *localStore) SetPlayer(p player) error {
+	return l.set(fmt.Sprintf("player-%v", p.ID), p)
+}
+
+func (l *localStore) DelPlayer(ID int) error {
+	l.kv.Del(fmt.Sprintf("player-%v", ID))
+	return nil
+}
+
+func (l *localStore) Board(ID int) (board, error) {
+	Board := board{}
+	return Board, l.get(fmt.Sprintf("board-%v", ID), &Board)
+}
+
+func (l *localStore) SetBoard(b board) error {
+	return l.set(fmt.Sprintf("board-%v", b.ID), b)
+}
+
+func (l *localStore) BoardGames(ID int) ([]int, error) {
+	GameIDs := make([]int, 0)
+	return GameIDs, l.get(fmt.Sprintf("board-%v-games", ID), &GameIDs)
+}
+
+func (l *localStore) SetBoardGames(ID int, games []int) error {
+	return l.set(fmt.Sprintf("board-%v-games", ID), games)
+}
+
+func (l *localStore) DelBoard(ID int) error {
+	l.kv.Del(fmt.Sprintf("board-%v", ID))
+	l.kv.Del(fmt.Sprintf("board-%v-games", ID))
+	return nil
+}
+
+func (l *localStore) Location(ID int) (location, error) {
+	Location := location{}
+	return Location, l.get(fmt.Sprintf("location-%v", ID), &Location)
+}
+
+func (l *localStore) SetLocation(loc location) error {
+	return l.set(fmt.Sprintf("location-%v", loc.ID), loc)
+}
+
+func (l *localStore) Exists(kind string, ID int) (bool, error) {
+	var raw json.RawMessage
+	if err := l.get(fmt.Sprintf("%v-%v", kind, ID), &raw); err != nil {
+		return false, err
+	}
+	return raw != nil, nil
+}
+
+func (l *localStore) Clear() error {
+	// companion keys stored next to each record
+	suffixes := map[string][]string{
+		"session":  {"", "-games"},
+		"game":     {"", "-scores"},
+		"player":   {""},
+		"board":    {"", "-games"},
+		"location": {""},
+	}
+	for kind, suffix := range suffixes {
+		count, err := l.Count(kind)
+		if err != nil {
+			return err
+		}
+		for ID := 0; ID < count; ID++ {
+			for _, suf := range suffix {
+				l.kv.Del(fmt.Sprintf("%v-%v%v", kind, ID, suf))
+			}
+		}
+		l.kv.Del(kind + "-count")
+	}
+	return nil
+}
+
+// Batch journals the writes of fn. In a nested batch the journal writes
+// through the journal of the outer batch, so rolling back the inner one is
+// itself undone if the outer one fails.
+func (l *localStore) Batch(fn func(Store) error) error {
+	j := newJournal(l.kv)
+	if err := fn(&localStore{kv: j}); err != nil {
+		if rerr := j.rollback(); rerr != nil {
+			return errors.New("error rolling back batch").Wrap(rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// journal is a browser storage that remembers the value each key had before
+// it was first written, so the writes can be undone.
+type journal struct {
+	kv app.BrowserStorage
+	// nil for keys that did not exist
+	saved map[string]json.RawMessage
+	order []string
+}
+
+func newJournal(kv app.BrowserStorage) *journal {
+	return &journal{kv: kv, saved: make(map[string]json.RawMessage)}
+}
+
+func (j *journal) save(k string) error {
+	if _, ok := j.saved[k]; ok {
+		return nil
+	}
+	var previous json.RawMessage
+	if err := j.kv.Get(k, &previous); err != nil {
+		return err
+	}
+	j.saved[k] = previous
+	j.order = append(j.order, k)
+	return nil
+}
+
+func (j *journal) Set(k string, v interface{}) error {
+	if err := j.save(k); err != nil {
+		return err
+	}
+	return j.kv.Set(k, v)
+}
+
+func (j *journal) Get(k string, v interface{}) error {
+	return j.kv.Get(k, v)
+}
+
+func (j *journal) Del(k string) {
+	if err := j.save(k); err != nil {
+		app.Log("%s", errors.Newf("error saving %v before deleting it", k).Wrap(err))
+	}
+	j.kv.Del(k)
+}
+
+func (j *journal) Len() int {
+	return j.kv.Len()
+}
+
+func (j *journal) Key(i int) (string, error) {
+	return j.kv.Key(i)
+}
+
+func (j *journal) Clear() {
+	for j.kv.Len() > 0 {
+		k, err := j.kv.Key(0)
+		if err != nil {
+			return
+		}
+		j.Del(k)
+	}
+}
+
+// rollback restores as many keys as it can and reports the first failure.
+func (j *journal) rollback() error {
+	var failed error
+	for idx := len(j.order) - 1; idx >= 0; idx-- {
+		k := j.order[idx]
+		if j.saved[k] == nil {
+			j.kv.Del(k)
+			continue
+		}
+		if err := j.kv.Set(k, j.saved[k]); err != nil && failed == nil {
+			failed = errors.Newf("error restoring %v", k).Wrap(err)
+		}
+	}
+	return failed
+}
+
+// memoryStore keeps the records in Go maps. Missing records read back as
+// zero values, the same as with LocalStorage.
+type memoryStore struct {
+	counts       map[string]int
+	sessions     map[int]session
+	sessionGames map[int][]int
+	games        map[int]game
+	gameScores   map[int]map[int]float32
+	players      map[int]player
+	boards       map[int]board
+	boardGames   map[int][]int
+	locations    map[int]location
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		counts:       make(map[string]int),
+		sessions:     make(map[int]session),
+		sessionGames: make(map[int][]int),
+		games:        make(map[int]game),
+		gameScores:   make(map[int]map[int]float32),
+		players:      make(map[int]player),
+		boards:       make(map[int]board),
+		boardGames:   make(map[int][]int),
+		locations:    make(map[int]location),
+	}
+}
+
+func copyScores(scores map[int]float32) map[int]float32 {
+	Copy := make(map[int]float32, len(scores))
+	for k, v := range scores {
+		Copy[k] = v
+	}
+	return Copy
+}
+
+func copyGame(g game) game {
+	if g.Players != nil {
+		g.Players = append([]int{}, g.Players...)
+	}
+	if g.Teams != nil {
+		Teams := make([]team, len(g.Teams))
+		for idx, Team := range g.Teams {
+			Team.Players = append([]int{}, Team.Players...)
+			Teams[idx] = Team
+		}
+		g.Teams = Teams
+	}
+	if g.Categories != nil {
+		g.Categories = append([]string{}, g.Categories...)
+	}
+	if g.Expansions != nil {
+		g.Expansions = append([]int{}, g.Expansions...)
+	}
+	if g.Factions != nil {
+		Factions := make(map[int]string, len(g.Factions))
+		for Player, Faction := range g.Factions {
+			Factions[Player] = Faction
+		}
+		g.Factions = Factions
+	}
+	if g.Breakdown != nil {
+		Breakdown := make(map[int]map[string]float32, len(g.Breakdown))
+		for Player, Sheet := range g.Breakdown {
+			Breakdown[Player] = make(map[string]float32, len(Sheet))
+			for Category, Score := range Sheet {
+				Breakdown[Player][Category] = Score
+			}
+		}
+		g.Breakdown = Breakdown
+	}
+	return g
+}
+
+func (m *memoryStore) Count(kind string) (int, error) {
+	return m.counts[kind], nil
+}
+
+func (m *memoryStore) SetCount(kind string, count int) error {
+	m.counts[kind] = count
+	return nil
+}
+
+func (m *memoryStore) Session(ID int) (session, error) {
+	return copySession(m.sessions[ID]), nil
+}
+
+func (m *memoryStore) SetSession(s session) error {
+	m.sessions[s.ID] = copySession(s)
+	return nil
+}
+
+func copySession(s session) session {
+	if s.Attendees != nil {
+		s.Attendees = append([]int{}, s.Attendees...)
+	}
+	return s
+}
+
+func (m *memoryStore) SessionGames(ID int) ([]int, error) {
+	return append([]int{}, m.sessionGames[ID]...), nil
+}
+
+func (m *memoryStore) SetSessionGames(ID int, games []int) error {
+	m.sessionGames[ID] = append([]int{}, games...)
+	return nil
+}
+
+func (m *memoryStore) DelSession(ID int) error {
+	delete(m.sessions, ID)
+	delete(m.sessionGames, ID)
+	return nil
+}
+
+func (m *memoryStore) Game(ID int) (game, error) {
+	return copyGame(m.games[ID]), nil
+}
+
+func (m *memoryStore) SetGame(g game) error {
+	m.games[g.ID] = copyGame(g)
+	return nil
+}
+
+func (m *memoryStore) GameScores(ID int) (map[int]float32, error) {
+	return copyScores(m.gameScores[ID]), nil
+}
+
+func (m *memoryStore) SetGameScores(ID int, scores map[int]float32) error {
+	m.gameScores[ID] = copyScores(scores)
+	return nil
+}
+
+func (m *memoryStore) DelGame(ID int) error {
+	delete(m.games, ID)
+	delete(m.gameScores, ID)
+	return nil
+}
+
+func (m *memoryStore) Player(ID int) (player, error) {
+	return m.players[ID], nil
+}
+
+func (m *memoryStore) SetPlayer(p player) error {
+	m.players[p.ID] = p
+	return nil
+}
+
+func (m *memoryStore) DelPlayer(ID int) error {
+	delete(m.players, ID)
+	return nil
+}
+
+func (m *memoryStore) Board(ID int) (board, error) {
+	return copyBoard(m.boards[ID]), nil
+}
+
+func (m *memoryStore) SetBoard(b board) error {
+	m.boards[b.ID] = copyBoard(b)
+	return nil
+}
+
+func copyBoard(b board) board {
+	if b.Categories != nil {
+		b.Categories = append([]string{}, b.Categories...)
+	}
+	if b.Factions != nil {
+		b.Factions = append([]string{}, b.Factions...)
+	}
+	return b
+}
+
+func (m *memoryStore) BoardGames(ID int) ([]int, error) {
+	return append([]int{}, m.boardGames[ID]...), nil
+}
+
+func (m *memoryStore) SetBoardGames(ID int, games []int) error {
+	m.boardGames[ID] = append([]int{}, games...)
+	return nil
+}
+
+func (m *memoryStore) DelBoard(ID int) error {
+	delete(m.boards, ID)
+	delete(m.boardGames, ID)
+	return nil
+}
+
+func (m *memoryStore) Location(ID int) (location, error) {
+	return m.locations[ID], nil
+}
+
+func (m *memoryStore) SetLocation(l location) error {
+	m.locations[l.ID] = l
+	return nil
+}
+
+func (m *memoryStore) Exists(kind string, ID int) (bool, error) {
+	ok := false
+	switch kind {
+	case "session":
+		_, ok = m.sessions[ID]
+	case "game":
+		_, ok = m.games[ID]
+	case "player":
+		_, ok = m.players[ID]
+	case "board":
+		_, ok = m.boards[ID]
+	case "location":
+		_, ok = m.locations[ID]
+	default:
+		return false, errors.Newf("unknown record kind %v", kind)
+	}
+	return ok, nil
+}
+
+func (m *memoryStore) Clear() error {
+	*m = *newMemoryStore()
+	return nil
+}
+
+// Batch snapshots the whole store and puts the snapshot back if fn fails. A
+// nested batch takes its own snapshot, so it only undoes its own writes.
+func (m *memoryStore) Batch(fn func(Store) error) error {
+	snapshot := m.clone()
+	err := fn(m)
+	if err != nil {
+		*m = *snapshot
+	}
+	return err
+}
+
+func (m *memoryStore) clone() *memoryStore {
+	Copy := newMemoryStore()
+	for k, v := range m.counts {
+		Copy.counts[k] = v
+	}
+	for k, v := range m.sessions {
+		Copy.sessions[k] = copySession(v)
+	}
+	for k, v := range m.sessionGames {
+		Copy.sessionGames[k] = append([]int{}, v...)
+	}
+	for k, v := range m.games {
+		Copy.games[k] = copyGame(v)
+	}
+	for k, v := range m.gameScores {
+		Copy.gameScores[k] = copyScores(v)
+	}
+	for k, v := range m.players {
+		Copy.players[k] = v
+	}
+	for k, v := range m.boards {
+		Copy.boards[k] = copyBoard(v)
+	}
+	for k, v := range m.boardGames {
+		Copy.boardGames[k] = append([]int{}, v...)
+	}
+	for k, v := range m.locations {
+		Copy.locations[k] = v
+	}
+	return Copy
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/maxence-charriere/go-app/v7/pkg/app"
+
+	"github.com/Textualization/boardgame-logbook/stats"
+)
+
+// forEachStore runs the test against every Store backend. Outside wasm,
+// app.LocalStorage is kept in memory, so the localStore runs here too.
+func forEachStore(t *testing.T, test func(t *testing.T, st Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newMemoryStore())
+	})
+	t.Run("local", func(t *testing.T) {
+		app.LocalStorage.Clear()
+		defer app.LocalStorage.Clear()
+		test(t, newLocalStore(app.LocalStorage))
+	})
+}
+
+func TestStoreCounts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		if count, err := st.Count("game"); err != nil || count != 0 {
+			t.Fatalf("Count of empty store = %v, %v; want 0", count, err)
+		}
+		for want := 0; want < 3; want++ {
+			Player, err := newPlayer(st, "Ann")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if Player.ID != want {
+				t.Errorf("newPlayer ID = %v; want %v", Player.ID, want)
+			}
+		}
+		if count, _ := st.Count("player"); count != 3 {
+			t.Errorf("player count = %v; want 3", count)
+		}
+		if err := st.SetCount("board", 7); err != nil {
+			t.Fatal(err)
+		}
+		if count, _ := st.Count("board"); count != 7 {
+			t.Errorf("board count = %v; want 7", count)
+		}
+	})
+}
+
+func TestStoreRecords(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		Session := session{ID: 2, Date: 1600000000, Notes: "first", Attendees: []int{1, 3}}
+		if err := st.SetSession(Session); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.Session(2); !reflect.DeepEqual(got, Session) {
+			t.Errorf("Session = %+v; want %+v", got, Session)
+		}
+		if err := st.SetSessionGames(2, []int{4, 5}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.SessionGames(2); !reflect.DeepEqual(got, []int{4, 5}) {
+			t.Errorf("SessionGames = %v; want [4 5]", got)
+		}
+
+		Game := game{ID: 4, Board: 1, Session: 2, Players: []int{1, 3},
+			Factions: map[int]string{1: "Cats"}, Expansions: []int{6}}
+		Scores := map[int]float32{1: 10, 3: 12.5}
+		if err := st.SetGame(Game); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.SetGameScores(4, Scores); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.Game(4); !reflect.DeepEqual(got, Game) {
+			t.Errorf("Game = %+v; want %+v", got, Game)
+		}
+		if got, _ := st.GameScores(4); !reflect.DeepEqual(got, Scores) {
+			t.Errorf("GameScores = %v; want %v", got, Scores)
+		}
+
+		Board := board{ID: 1, Text: "Root", Scoring: stats.HighestWins, Factions: []string{"Cats", "Birds"}}
+		if err := st.SetBoard(Board); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.Board(1); !reflect.DeepEqual(got, Board) {
+			t.Errorf("Board = %+v; want %+v", got, Board)
+		}
+		if err := st.SetBoardGames(1, []int{4}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.BoardGames(1); !reflect.DeepEqual(got, []int{4}) {
+			t.Errorf("BoardGames = %v; want [4]", got)
+		}
+		Player := player{ID: 3, Text: "Bob"}
+		if err := st.SetPlayer(Player); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.Player(3); got != Player {
+			t.Errorf("Player = %+v; want %+v", got, Player)
+		}
+		Location := location{ID: 0, Text: "Ann's"}
+		if err := st.SetLocation(Location); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.Location(0); got != Location {
+			t.Errorf("Location = %+v; want %+v", got, Location)
+		}
+
+		for _, kind := range []string{"session", "game", "board", "player", "location"} {
+			if ok, err := st.Exists(kind, 9); err != nil || ok {
+				t.Errorf("Exists(%v, 9) = %v, %v; want false", kind, ok, err)
+			}
+		}
+		if err := st.DelGame(4); err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := st.Exists("game", 4); ok {
+			t.Error("game 4 exists after DelGame")
+		}
+		if got, _ := st.GameScores(4); len(got) != 0 {
+			t.Errorf("GameScores after DelGame = %v; want none", got)
+		}
+		if err := st.DelSession(2); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.SessionGames(2); len(got) != 0 {
+			t.Errorf("SessionGames after DelSession = %v; want none", got)
+		}
+		if err := st.DelBoard(1); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.BoardGames(1); len(got) != 0 {
+			t.Errorf("BoardGames after DelBoard = %v; want none", got)
+		}
+		if err := st.DelPlayer(3); err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := st.Exists("player", 3); ok {
+			t.Error("player 3 exists after DelPlayer")
+		}
+	})
+}
+
+func TestStoreClear(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		Session, _ := newSession(st)
+		Board, _ := newBoard(st, "Azul")
+		if _, err := newGame(st, game{Board: Board.ID, Session: Session.ID}, map[int]float32{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Clear(); err != nil {
+			t.Fatal(err)
+		}
+		for _, kind := range []string{"session", "game", "board"} {
+			if count, _ := st.Count(kind); count != 0 {
+				t.Errorf("%v count after Clear = %v; want 0", kind, count)
+			}
+			if ok, _ := st.Exists(kind, 0); ok {
+				t.Errorf("%v 0 exists after Clear", kind)
+			}
+		}
+	})
+}
+
+var errTest = errors.New("test failure")
+
+func TestStoreBatchRollback(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		Ann, _ := newPlayer(st, "Ann")
+		err := st.Batch(func(st Store) error {
+			Ann.Text = "Anne"
+			if err := storePlayer(st, Ann); err != nil {
+				return err
+			}
+			if _, err := newPlayer(st, "Bob"); err != nil {
+				return err
+			}
+			return errTest
+		})
+		if err != errTest {
+			t.Fatalf("Batch = %v; want %v", err, errTest)
+		}
+		if got, _ := st.Player(Ann.ID); got.Text != "Ann" {
+			t.Errorf("player after rollback = %q; want Ann", got.Text)
+		}
+		if count, _ := st.Count("player"); count != 1 {
+			t.Errorf("player count after rollback = %v; want 1", count)
+		}
+		if ok, _ := st.Exists("player", 1); ok {
+			t.Error("player created in the failed batch still exists")
+		}
+	})
+}
+
+func TestStoreBatchNested(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		newPlayer(st, "Ann")
+		err := st.Batch(func(st Store) error {
+			if err := st.Clear(); err != nil {
+				return err
+			}
+			if _, err := newPlayer(st, "Bob"); err != nil {
+				return err
+			}
+			// a failed inner batch undoes its own writes, and only them
+			inner := st.Batch(func(st Store) error {
+				Bob, err := st.Player(0)
+				if err != nil {
+					return err
+				}
+				Bob.Text = "Robert"
+				if err := storePlayer(st, Bob); err != nil {
+					return err
+				}
+				if _, err := newPlayer(st, "Cid"); err != nil {
+					return err
+				}
+				return errTest
+			})
+			if inner != errTest {
+				t.Errorf("inner Batch = %v; want %v", inner, errTest)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := st.Player(0); got.Text != "Bob" {
+			t.Errorf("player 0 = %q; want Bob from before the inner batch", got.Text)
+		}
+		if count, _ := st.Count("player"); count != 1 {
+			t.Errorf("player count = %v; want 1, Cid rolled back", count)
+		}
+		if ok, _ := st.Exists("player", 1); ok {
+			t.Error("player created in the failed inner batch still exists")
+		}
+
+		// the writes of a successful inner batch are undone with the outer one
+		err = st.Batch(func(st Store) error {
+			if err := st.Clear(); err != nil {
+				return err
+			}
+			if err := st.Batch(func(st Store) error {
+				_, err := newPlayer(st, "Dee")
+				return err
+			}); err != nil {
+				return err
+			}
+			return errTest
+		})
+		if err != errTest {
+			t.Fatalf("Batch = %v; want %v", err, errTest)
+		}
+		if got, _ := st.Player(0); got.Text != "Bob" {
+			t.Errorf("player 0 after rollback = %q; want Bob", got.Text)
+		}
+		if count, _ := st.Count("player"); count != 1 {
+			t.Errorf("player count after rollback = %v; want 1", count)
+		}
+	})
+}